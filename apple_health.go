@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Written in the same shape as the export.xml Apple Health produces, which is
+// what iOS import tools read back in
+type healthData struct {
+	XMLName    xml.Name        `xml:"HealthData"`
+	Locale     string          `xml:"locale,attr"`
+	ExportDate healthDateValue `xml:"ExportDate"`
+	Workouts   []healthWorkout `xml:"Workout"`
+}
+
+type healthDateValue struct {
+	Value string `xml:"value,attr"`
+}
+
+type healthWorkout struct {
+	ActivityType          string `xml:"workoutActivityType,attr"`
+	Duration              string `xml:"duration,attr"`
+	DurationUnit          string `xml:"durationUnit,attr"`
+	TotalDistance         string `xml:"totalDistance,attr,omitempty"`
+	TotalDistanceUnit     string `xml:"totalDistanceUnit,attr,omitempty"`
+	TotalEnergyBurned     string `xml:"totalEnergyBurned,attr,omitempty"`
+	TotalEnergyBurnedUnit string `xml:"totalEnergyBurnedUnit,attr,omitempty"`
+	SourceName            string `xml:"sourceName,attr"`
+	CreationDate          string `xml:"creationDate,attr"`
+	StartDate             string `xml:"startDate,attr"`
+	EndDate               string `xml:"endDate,attr"`
+}
+
+const (
+	healthDateFormat      = "2006-01-02 15:04:05 -0700"
+	healthTypePrefix      = "HKWorkoutActivityType"
+	healthDefaultStrength = "TraditionalStrengthTraining"
+	healthDefaultCardio   = "Other"
+	healthSourceName      = "Fitocracy"
+	healthDefaultMETs     = 4.0
+)
+
+// Rough MET values per activity type, used to estimate energy burned
+var healthMETs = map[string]float64{
+	healthDefaultStrength:        5.0,
+	"FunctionalStrengthTraining": 5.0,
+	"CoreTraining":               3.8,
+	"Running":                    9.8,
+	"Cycling":                    7.5,
+	"Walking":                    3.5,
+	"Hiking":                     6.0,
+	"Elliptical":                 5.0,
+	"StairClimbing":              9.0,
+	"Yoga":                       3.0,
+	"Basketball":                 6.5,
+}
+
+// Writes Apple Health Workout elements. The strength sets of each Fitocracy
+// workout become one workout, typed by the most common apple_activity_type
+// (or the type implied by exercise metadata) among them, and every cardio
+// action (anything with a time or distance) becomes a workout of its own.
+// Energy is only estimated when a body_weight is configured.
+type AppleHealthExporter struct {
+	BodyWeightKg float64
+}
+
+func (a AppleHealthExporter) FileExtension() string {
+	return "xml"
+}
+
+func (a AppleHealthExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	data := healthData{
+		Locale:     "en_US",
+		ExportDate: healthDateValue{time.Now().Format(healthDateFormat)},
+	}
+
+	for _, group := range GroupWorkouts(details) {
+		strength := &WorkoutGroup{Id: group.Id}
+		counts := map[string]int{}
+		for _, detail := range group.Details {
+			activityType := exerciseMapper.ByFitocracyId[detail.Activity.Id].appleActivityType()
+			if detail.IsCardio() {
+				if activityType == "" {
+					activityType = healthDefaultCardio
+				}
+				start := detail.PerformedAt
+				duration := time.Duration(detail.Duration) * time.Second
+				data.Workouts = append(data.Workouts, a.workout(activityType, start, duration, detail.Distance))
+				continue
+			}
+			if activityType == "" {
+				activityType = healthDefaultStrength
+			}
+			counts[activityType]++
+			strength.Details = append(strength.Details, detail)
+		}
+		if len(strength.Details) == 0 {
+			continue
+		}
+
+		activityType := healthDefaultStrength
+		for t, count := range counts {
+			if count > counts[activityType] || (count == counts[activityType] && t < activityType) {
+				activityType = t
+			}
+		}
+		data.Workouts = append(data.Workouts, a.workout(activityType, strength.Start(), strength.Duration(), 0))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", " ")
+	return encoder.Encode(data)
+}
+
+func (a AppleHealthExporter) workout(activityType string, start time.Time, duration time.Duration, distanceMeters float64) healthWorkout {
+	workout := healthWorkout{
+		ActivityType: healthTypePrefix + activityType,
+		Duration:     fmt.Sprintf("%.2f", duration.Minutes()),
+		DurationUnit: "min",
+		SourceName:   healthSourceName,
+		CreationDate: start.Format(healthDateFormat),
+		StartDate:    start.Format(healthDateFormat),
+		EndDate:      start.Add(duration).Format(healthDateFormat),
+	}
+	if distanceMeters > 0 {
+		workout.TotalDistance = fmt.Sprintf("%.3f", distanceMeters/1000)
+		workout.TotalDistanceUnit = "km"
+	}
+	if a.BodyWeightKg > 0 {
+		mets, ok := healthMETs[activityType]
+		if !ok {
+			mets = healthDefaultMETs
+		}
+		workout.TotalEnergyBurned = fmt.Sprintf("%.1f", mets*a.BodyWeightKg*duration.Hours())
+		workout.TotalEnergyBurnedUnit = "kcal"
+	}
+	return workout
+}
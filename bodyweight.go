@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/viper"
+)
+
+// Read body weights from a CSV with a header row naming date and weight
+// columns, and optionally units (defaulting to defaultUnits). Dates are
+// YYYY-MM-DD in the location, or RFC 3339 timestamps.
+func ParseBodyWeightCSV(r io.Reader, defaultUnits string, location *time.Location) (weights []BodyWeight, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 //trailing units are optional
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	dateColumn, hasDate := columns["date"]
+	weightColumn, hasWeight := columns["weight"]
+	unitsColumn, hasUnits := columns["units"]
+	if !hasDate || !hasWeight {
+		return nil, fmt.Errorf("body weight csv needs date and weight columns")
+	}
+
+	for line, record := range records[1:] {
+		if len(record) <= dateColumn || len(record) <= weightColumn {
+			return nil, fmt.Errorf("line %d: missing date or weight", line+2)
+		}
+		w := BodyWeight{Units: defaultUnits}
+		date := strings.TrimSpace(record[dateColumn])
+		w.MeasuredAt, err = time.ParseInLocation(filterDateFormat, date, location)
+		if err != nil {
+			w.MeasuredAt, err = time.Parse(time.RFC3339, date)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line+2, date)
+		}
+		w.MeasuredAt = w.MeasuredAt.UTC()
+		w.Weight, err = strconv.ParseFloat(strings.TrimSpace(record[weightColumn]), 64)
+		if err != nil || w.Weight <= 0 {
+			return nil, fmt.Errorf("line %d: invalid weight %q", line+2, record[weightColumn])
+		}
+		if hasUnits && unitsColumn < len(record) && strings.TrimSpace(record[unitsColumn]) != "" {
+			w.Units = strings.TrimSpace(record[unitsColumn])
+		}
+		if !isWeightUnit(w.Units) {
+			return nil, fmt.Errorf("line %d: unknown units %q", line+2, w.Units)
+		}
+		weights = append(weights, w)
+	}
+	return
+}
+
+// A body weight at a point in time, in the units being reported in
+type BodyWeightPoint struct {
+	Time   time.Time
+	Weight float64
+	Source string
+}
+
+// Every known body weight in order: imported measurements, and the sets of
+// the activity Fitocracy users logged their weight with
+func BodyWeightSeries(imported []BodyWeight, logged []UserActivityDetail, units string) (series []BodyWeightPoint) {
+	for _, w := range imported {
+		series = append(series, BodyWeightPoint{w.MeasuredAt, ConvertWeight(w.Weight, w.Units, units), "import"})
+	}
+	for _, detail := range logged {
+		if detail.Weight > 0 && isWeightUnit(detail.Units) {
+			series = append(series, BodyWeightPoint{detail.PerformedAt, ConvertWeight(detail.Weight, detail.Units, units), "fitocracy"})
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool { return series[i].Time.Before(series[j].Time) })
+	return
+}
+
+// The body weight at a time: the latest measured by then, or failing that
+// the earliest measured at all, or failing that the fallback
+func BodyWeightAt(series []BodyWeightPoint, t time.Time, fallback float64) float64 {
+	i := sort.Search(len(series), func(i int) bool { return series[i].Time.After(t) })
+	switch {
+	case i > 0:
+		return series[i-1].Weight
+	case len(series) > 0:
+		return series[0].Weight
+	}
+	return fallback
+}
+
+// The user's body weights, in weight_units, from the body_weights table and
+// the body_weight_activity set in config.toml
+func userBodyWeightSeries(db *sqlx.DB, username string) ([]BodyWeightPoint, error) {
+	err, user := GetUserByUsername(db, username)
+	if nil != err {
+		return nil, err
+	}
+	err, imported := GetBodyWeights(db, user.Id)
+	if nil != err {
+		return nil, err
+	}
+	logged := []UserActivityDetail{}
+	if activity := viper.GetString("body_weight_activity"); activity != "" {
+		err, logged = GetUserActivityDetails(db, user.Id, ActivityFilter{Exercises: []string{activity}})
+		if nil != err {
+			return nil, err
+		}
+	}
+	return BodyWeightSeries(imported, logged, viper.GetString("weight_units")), nil
+}
+
+// Handles "bodyweight import|list"
+func runBodyWeight(db *sqlx.DB, username string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: bodyweight import|list")
+	}
+	switch args[0] {
+	case "import":
+		return runBodyWeightImport(db, username, args[1:])
+	case "list":
+		return runBodyWeightList(db, username, args[1:])
+	}
+	return fmt.Errorf("unknown bodyweight command %s", args[0])
+}
+
+// Handles "bodyweight import -file=<csv>"
+func runBodyWeightImport(db *sqlx.DB, username string, args []string) error {
+	importFlags := flag.NewFlagSet("bodyweight import", flag.ExitOnError)
+	file := importFlags.String("file", "", "CSV with date, weight and optionally units columns")
+	importFlags.Parse(args)
+
+	if *file == "" {
+		importFlags.PrintDefaults()
+		return fmt.Errorf("a body weight csv to import is required")
+	}
+	err, user := GetUserByUsername(db, username)
+	if nil != err {
+		return err
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	weights, err := ParseBodyWeightCSV(f, viper.GetString("weight_units"), configuredLocation())
+	if err != nil {
+		return err
+	}
+	if err = SaveBodyWeights(db, user.Id, weights); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d body weights from %s\n", len(weights), *file)
+	return nil
+}
+
+// Handles "bodyweight list": every known body weight
+func runBodyWeightList(db *sqlx.DB, username string, args []string) error {
+	listFlags := flag.NewFlagSet("bodyweight list", flag.ExitOnError)
+	format := addReportFormatFlag(listFlags)
+	listFlags.Parse(args)
+
+	series, err := userBodyWeightSeries(db, username)
+	if err != nil {
+		return err
+	}
+	report := NewReport(configuredLocation(), "date", "weight", "units", "source")
+	for _, point := range series {
+		report.AddRow(point.Time, point.Weight, viper.GetString("weight_units"), point.Source)
+	}
+	return report.Write(os.Stdout, *format)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// A line chart of values over time, with a line per series
+type LineChart struct {
+	Title  string
+	YLabel string
+	Series []ChartSeries
+}
+
+type ChartSeries struct {
+	Id     int //of what's plotted, e.g. the activity
+	Name   string
+	Points []ChartPoint
+}
+
+type ChartPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// Line colours, in series order
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+const (
+	chartWidth  = 720
+	chartHeight = 360
+	chartLeft   = 60
+	chartRight  = 20
+	chartTop    = 40
+	chartBottom = 40
+	chartLegend = 16 //height of each legend line
+)
+
+type chartTick struct {
+	Pos   float64
+	Label string
+}
+
+type chartLine struct {
+	Name   string
+	Color  string
+	Points string
+	Dots   []chartDot
+	Legend float64
+}
+
+type chartDot struct {
+	X, Y  float64
+	Title string
+}
+
+type chartData struct {
+	Title, YLabel            string
+	Width, Height            int
+	Left, Right, Top, Bottom float64
+	TickEnd                  float64
+	XTicks, YTicks           []chartTick
+	Lines                    []chartLine
+	ShowLegend               bool
+	LegendX                  float64
+	LegendY                  float64
+	Empty                    bool
+}
+
+const lineChartTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" font-family="sans-serif" font-size="11" fill="#333">
+<text x="{{.Left}}" y="20" font-size="14">{{.Title}}</text>
+<text transform="translate(14,{{.Bottom}}) rotate(-90)" x="0" y="0">{{.YLabel}}</text>
+{{- range .YTicks}}
+<line x1="{{$.Left}}" x2="{{$.Right}}" y1="{{.Pos}}" y2="{{.Pos}}" stroke="#e5e5e5"/>
+<text x="{{$.Left}}" y="{{.Pos}}" dx="-6" dy="4" text-anchor="end">{{.Label}}</text>
+{{- end}}
+{{- range .XTicks}}
+<line x1="{{.Pos}}" x2="{{.Pos}}" y1="{{$.Bottom}}" y2="{{$.TickEnd}}" stroke="#999"/>
+<text x="{{.Pos}}" y="{{$.Bottom}}" dy="16" text-anchor="middle">{{.Label}}</text>
+{{- end}}
+<line x1="{{.Left}}" x2="{{.Right}}" y1="{{.Bottom}}" y2="{{.Bottom}}" stroke="#999"/>
+{{- if .Empty}}
+<text x="{{.LegendX}}" y="{{.LegendY}}" text-anchor="middle">No data</text>
+{{- end}}
+{{- range .Lines}}
+<polyline points="{{.Points}}" fill="none" stroke="{{.Color}}" stroke-width="2"/>
+{{- $color := .Color}}
+{{- range .Dots}}
+<circle cx="{{.X}}" cy="{{.Y}}" r="3" fill="{{$color}}"><title>{{.Title}}</title></circle>
+{{- end}}
+{{- end}}
+{{- if .ShowLegend}}
+{{- range .Lines}}
+<rect x="{{$.LegendX}}" y="{{.Legend}}" width="10" height="10" fill="{{.Color}}"/>
+<text x="{{$.LegendX}}" y="{{.Legend}}" dx="14" dy="9">{{.Name}}</text>
+{{- end}}
+{{- end}}
+</svg>
+`
+
+var lineChartSVG = template.Must(template.New("chart").Parse(lineChartTemplate))
+
+// Round numbers for about count ticks spanning min to max
+func niceTicks(min float64, max float64, count int) (ticks []float64) {
+	if max <= min {
+		max = min + 1
+	}
+	raw := (max - min) / float64(count)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude * 10
+	for _, multiple := range []float64{1, 2, 2.5, 5, 10} {
+		if magnitude*multiple >= raw {
+			step = magnitude * multiple
+			break
+		}
+	}
+	for tick := math.Floor(min/step) * step; tick <= math.Ceil(max/step)*step+step/2; tick += step {
+		ticks = append(ticks, tick)
+	}
+	return
+}
+
+// Dates for the time axis: the first of every month, quarter or year,
+// whichever gives a readable number of ticks
+func timeTicks(start time.Time, end time.Time) (ticks []time.Time, layout string) {
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	step, layout := 1, "Jan 2006"
+	switch {
+	case months > 8*12:
+		step, layout = 24, "2006"
+	case months > 4*12:
+		step, layout = 12, "2006"
+	case months > 24:
+		step, layout = 6, "Jan 2006"
+	case months > 8:
+		step, layout = 3, "Jan 2006"
+	}
+	tick := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	if step >= 12 {
+		tick = time.Date(start.Year(), 1, 1, 0, 0, 0, 0, start.Location())
+	}
+	for ; !tick.After(end); tick = tick.AddDate(0, step, 0) {
+		if !tick.Before(start) {
+			ticks = append(ticks, tick)
+		}
+	}
+	return
+}
+
+func (c LineChart) WriteSVG(w io.Writer) error {
+	//the template is text/template, so names are escaped here
+	data := chartData{
+		Title:      html.EscapeString(c.Title),
+		YLabel:     html.EscapeString(c.YLabel),
+		Width:      chartWidth,
+		Height:     chartHeight,
+		Left:       chartLeft,
+		Right:      chartWidth - chartRight,
+		Top:        chartTop,
+		Bottom:     chartHeight - chartBottom,
+		TickEnd:    chartHeight - chartBottom + 4,
+		ShowLegend: len(c.Series) > 1,
+	}
+
+	var start, end time.Time
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, series := range c.Series {
+		for _, point := range series.Points {
+			if start.IsZero() || point.Time.Before(start) {
+				start = point.Time
+			}
+			if point.Time.After(end) {
+				end = point.Time
+			}
+			min = math.Min(min, point.Value)
+			max = math.Max(max, point.Value)
+		}
+	}
+	if start.IsZero() {
+		data.Empty = true
+		data.LegendX = (data.Left + data.Right) / 2
+		data.LegendY = (data.Top + data.Bottom) / 2
+		return lineChartSVG.Execute(w, data)
+	}
+	if !end.After(start) {
+		start, end = start.AddDate(0, 0, -1), end.AddDate(0, 0, 1)
+	}
+
+	//leave some room below the lowest value rather than starting at zero
+	yTicks := niceTicks(math.Max(0, min-(max-min)*0.1), max, 5)
+	yMin, yMax := yTicks[0], yTicks[len(yTicks)-1]
+	x := func(t time.Time) float64 {
+		return round(data.Left+(data.Right-data.Left)*t.Sub(start).Seconds()/end.Sub(start).Seconds(), 1)
+	}
+	y := func(v float64) float64 {
+		return round(data.Bottom-(data.Bottom-data.Top)*(v-yMin)/(yMax-yMin), 1)
+	}
+	for _, tick := range yTicks {
+		data.YTicks = append(data.YTicks, chartTick{y(tick), fmt.Sprint(round(tick, 2))})
+	}
+	ticks, layout := timeTicks(start, end)
+	for _, tick := range ticks {
+		data.XTicks = append(data.XTicks, chartTick{x(tick), tick.Format(layout)})
+	}
+
+	data.LegendX = data.Left + 10
+	for i, series := range c.Series {
+		line := chartLine{
+			Name:   html.EscapeString(series.Name),
+			Color:  chartColors[i%len(chartColors)],
+			Legend: data.Top + float64(i*chartLegend),
+		}
+		points := []string{}
+		for _, point := range series.Points {
+			px, py := x(point.Time), y(point.Value)
+			points = append(points, fmt.Sprintf("%g,%g", px, py))
+			title := fmt.Sprintf("%s %s: %g", point.Time.Format(filterDateFormat), line.Name, round(point.Value, 1))
+			line.Dots = append(line.Dots, chartDot{px, py, title})
+		}
+		line.Points = strings.Join(points, " ")
+		data.Lines = append(data.Lines, line)
+	}
+	return lineChartSVG.Execute(w, data)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNiceTicks(t *testing.T) {
+	assert.Equal(t, []float64{100, 150, 200, 250, 300, 350}, niceTicks(107, 312, 5))
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75, 1}, niceTicks(0, 1, 4))
+}
+
+func TestProgressSeries(t *testing.T) {
+	mapper := NewExerciseMapper(nil)
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 2, 3, 110, "kg"),
+		testSet(3, 2, 2, 5, 105, "kg"),
+	}
+	series, err := ProgressSeries(details, mapper, "top_set", EstimateOneRepMax, "kg", 0)
+	assert.NoError(t, err)
+	assert.Len(t, series, 1)
+	assert.Equal(t, []float64{110, 105}, []float64{series[0].Points[0].Value, series[0].Points[1].Value})
+
+	series, _ = ProgressSeries(details, mapper, "volume", EstimateOneRepMax, "kg", 0)
+	assert.Equal(t, 830.0, series[0].Points[0].Value)
+
+	var buf bytes.Buffer
+	chart := LineChart{Title: "Squat & friends", YLabel: "kg", Series: series}
+	assert.NoError(t, chart.WriteSVG(&buf))
+	assert.Contains(t, buf.String(), "Squat &amp; friends")
+	assert.Equal(t, 2, strings.Count(buf.String(), "<circle"))
+
+	//e1RMs match stats 1rm: no body weight, and no sets in other units
+	mapper.ByFitocracyId[2] = Exercise{Bodyweight: true}
+	withCardio := append(details, testSet(4, 2, 2, 1, 45, "min"))
+	series, _ = ProgressSeries(withCardio, mapper, "e1rm", EstimateOneRepMax, "kg", 80)
+	best := BestSetPerWorkout(EstimateSets(withCardio, EstimateOneRepMax, "kg"))
+	assert.Equal(t, []float64{best[0].OneRepMax, best[1].OneRepMax}, []float64{series[0].Points[0].Value, series[0].Points[1].Value})
+
+	_, err = ProgressSeries(details, mapper, "reps", EstimateOneRepMax, "kg", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var comparePeriods = []string{"week", "month", "quarter", "year"}
+
+// Days from Start up to but not including End
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Whether a set logged at t falls in the range, going by its wall clock in
+// the range's location
+func (r DateRange) Contains(t time.Time) bool {
+	t = wallClock(t, r.Start.Location())
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// Parse START..END, both YYYY-MM-DD and inclusive, as days in the location
+func ParseDateRange(s string, location *time.Location) (r DateRange, err error) {
+	parts := strings.Split(s, "..")
+	if len(parts) != 2 {
+		return r, fmt.Errorf("invalid date range %q, expected YYYY-MM-DD..YYYY-MM-DD", s)
+	}
+	r.Start, err = time.ParseInLocation(filterDateFormat, parts[0], location)
+	if err != nil {
+		return r, fmt.Errorf("invalid date range %q: %s", s, err)
+	}
+	r.End, err = time.ParseInLocation(filterDateFormat, parts[1], location)
+	if err != nil {
+		return r, fmt.Errorf("invalid date range %q: %s", s, err)
+	}
+	r.End = r.End.AddDate(0, 0, 1)
+	if !r.Start.Before(r.End) {
+		return r, fmt.Errorf("invalid date range %q: ends before it starts", s)
+	}
+	return r, nil
+}
+
+// The week, month, quarter or year asOf falls in, and the one before it
+func CalendarPeriods(period string, asOf time.Time, location *time.Location) (current DateRange, previous DateRange, err error) {
+	asOf = wallClock(asOf, location)
+	years, months, days := 0, 0, 0
+	switch period {
+	case "week":
+		current.Start = periodStart(asOf, period, location)
+		days = 7
+	case "month":
+		current.Start = periodStart(asOf, period, location)
+		months = 1
+	case "quarter":
+		current.Start = time.Date(asOf.Year(), asOf.Month()-(asOf.Month()-1)%3, 1, 0, 0, 0, 0, location)
+		months = 3
+	case "year":
+		current.Start = time.Date(asOf.Year(), 1, 1, 0, 0, 0, 0, location)
+		years = 1
+	default:
+		return current, previous, fmt.Errorf("unknown period %q, expected one of %s", period, strings.Join(comparePeriods, ", "))
+	}
+	current.End = current.Start.AddDate(years, months, days)
+	previous = DateRange{current.Start.AddDate(-years, -months, -days), current.Start}
+	return
+}
+
+// What was done in a date range. Top set and best estimated 1RM are in the
+// units compared in; PRs counts sets that set any personal record.
+type PeriodStats struct {
+	Sessions      int
+	Sets          int
+	Reps          float64
+	Tonnage       float64
+	TopSet        float64
+	BestOneRepMax float64
+	PRs           int
+}
+
+// An exercise's stats (or every exercise's, with an Id of 0) in two ranges
+type PeriodComparison struct {
+	Id       int
+	Name     string
+	Previous PeriodStats
+	Current  PeriodStats
+}
+
+type CompareOptions struct {
+	Previous   DateRange
+	Current    DateRange
+	Formula    OneRepMaxFormula
+	Units      string
+	BodyWeight float64
+}
+
+// Compare each exercise performed in either range, then every exercise
+// together, busiest first. Details should be the whole history so PRs are
+// judged against everything that came before.
+func ComparePeriodStats(details []UserActivityDetail, exerciseMapper *ExerciseMapper, options CompareOptions) []PeriodComparison {
+	estimates := EstimateSets(details, options.Formula, options.Units)
+	estimated := map[int]SetEstimate{}
+	for _, e := range estimates {
+		estimated[e.UserActivity.Id] = e
+	}
+	recordSets := map[int]bool{}
+	for _, r := range PersonalRecords(estimates) {
+		recordSets[r.UserActivity.Id] = true
+	}
+
+	comparisons := map[int]*PeriodComparison{}
+	total := &PeriodComparison{Name: "All exercises"}
+	type session struct{ activity, workout, period int }
+	sessions := map[session]bool{}
+	for _, detail := range details {
+		var period int
+		switch {
+		case options.Previous.Contains(detail.PerformedAt):
+			period = 0
+		case options.Current.Contains(detail.PerformedAt):
+			period = 1
+		default:
+			continue
+		}
+		c, ok := comparisons[detail.Activity.Id]
+		if !ok {
+			c = &PeriodComparison{Id: detail.Activity.Id, Name: detail.Activity.Name}
+			comparisons[detail.Activity.Id] = c
+		}
+
+		for _, stats := range []*PeriodStats{c.stats(period), total.stats(period)} {
+			stats.Sets++
+			if recordSets[detail.UserActivity.Id] {
+				stats.PRs++
+			}
+			if detail.IsCardio() {
+				continue
+			}
+			stats.Reps += detail.Reps
+			stats.Tonnage += detail.Reps * setLoad(detail, exerciseMapper.ByFitocracyId[detail.Activity.Id], options.Units, options.BodyWeight)
+		}
+		if !sessions[session{detail.Activity.Id, detail.FitocracyGroupId, period}] {
+			sessions[session{detail.Activity.Id, detail.FitocracyGroupId, period}] = true
+			c.stats(period).Sessions++
+		}
+		if !sessions[session{0, detail.FitocracyGroupId, period}] {
+			sessions[session{0, detail.FitocracyGroupId, period}] = true
+			total.stats(period).Sessions++
+		}
+		if e, ok := estimated[detail.UserActivity.Id]; ok {
+			stats := c.stats(period)
+			if e.Weight > stats.TopSet {
+				stats.TopSet = e.Weight
+			}
+			if e.OneRepMax > stats.BestOneRepMax {
+				stats.BestOneRepMax = e.OneRepMax
+			}
+		}
+	}
+
+	compared := []PeriodComparison{}
+	for _, c := range comparisons {
+		compared = append(compared, *c)
+	}
+	sort.Slice(compared, func(i, j int) bool {
+		a, b := compared[i].Previous.Sets+compared[i].Current.Sets, compared[j].Previous.Sets+compared[j].Current.Sets
+		if a != b {
+			return a > b
+		}
+		return compared[i].Name < compared[j].Name
+	})
+	return append(compared, *total)
+}
+
+func (c *PeriodComparison) stats(period int) *PeriodStats {
+	if period == 0 {
+		return &c.Previous
+	}
+	return &c.Current
+}
+
+// The metrics a comparison reports, in order, with their values
+var compareMetrics = []struct {
+	name  string
+	value func(PeriodStats) float64
+}{
+	{"sessions", func(s PeriodStats) float64 { return float64(s.Sessions) }},
+	{"sets", func(s PeriodStats) float64 { return float64(s.Sets) }},
+	{"reps", func(s PeriodStats) float64 { return s.Reps }},
+	{"tonnage", func(s PeriodStats) float64 { return s.Tonnage }},
+	{"top_set", func(s PeriodStats) float64 { return s.TopSet }},
+	{"e1rm", func(s PeriodStats) float64 { return s.BestOneRepMax }},
+	{"prs", func(s PeriodStats) float64 { return float64(s.PRs) }},
+}
+
+// The change from previous to current as a percentage of previous, and
+// false when there's nothing to compare against
+func percentChange(previous float64, current float64) (float64, bool) {
+	if previous == 0 {
+		return 0, false
+	}
+	return (current - previous) / previous * 100, true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarPeriods(t *testing.T) {
+	current, previous, err := CalendarPeriods("quarter", time.Date(2016, 5, 20, 0, 0, 0, 0, time.UTC), time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC), current.Start)
+	assert.Equal(t, time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC), current.End)
+	assert.Equal(t, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), previous.Start)
+
+	r, err := ParseDateRange("2016-01-01..2016-01-31", time.UTC)
+	assert.NoError(t, err)
+	assert.True(t, r.Contains(time.Date(2016, 1, 31, 23, 0, 0, 0, time.UTC)))
+	assert.False(t, r.Contains(time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC)))
+	//set times are wall clocks, so they're in the range's days wherever it is
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	r, err = ParseDateRange("2016-01-01..2016-01-31", newYork)
+	assert.NoError(t, err)
+	assert.True(t, r.Contains(time.Date(2016, 1, 1, 0, 30, 0, 0, time.UTC)))
+	assert.False(t, r.Contains(time.Date(2016, 2, 1, 3, 0, 0, 0, time.UTC)))
+	_, err = ParseDateRange("2016-02-01..2016-01-01", time.UTC)
+	assert.Error(t, err)
+}
+
+func TestComparePeriodStats(t *testing.T) {
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 2, 5, 100, "kg"),
+		testSet(3, 2, 1, 5, 80, "kg"),
+		testSet(4, 10, 2, 5, 110, "kg"),
+		testSet(5, 11, 2, 3, 100, "kg"),
+		testSet(6, 11, 2, 1, 300, "sec"), //cardio synced without a duration
+	}
+	options := CompareOptions{
+		Previous: DateRange{time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 1, 8, 0, 0, 0, 0, time.UTC)},
+		Current:  DateRange{time.Date(2016, 1, 8, 0, 0, 0, 0, time.UTC), time.Date(2016, 1, 15, 0, 0, 0, 0, time.UTC)},
+		Formula:  EstimateOneRepMax,
+		Units:    "kg",
+	}
+	compared := ComparePeriodStats(details, NewExerciseMapper(nil), options)
+	assert.Len(t, compared, 3)
+
+	squat := compared[0]
+	assert.Equal(t, 2, squat.Id)
+	assert.Equal(t, PeriodStats{Sessions: 1, Sets: 2, Reps: 10, Tonnage: 1000, TopSet: 100, BestOneRepMax: EstimateOneRepMax(100, 5), PRs: 2}, squat.Previous)
+	//110x5 beats everything before it; 100x3 beats nothing
+	assert.Equal(t, 2, squat.Current.Sessions)
+	assert.Equal(t, 3, squat.Current.Sets)
+	assert.Equal(t, 1, squat.Current.PRs)
+	assert.Equal(t, 110.0, squat.Current.TopSet)
+
+	total := compared[2]
+	assert.Equal(t, "All exercises", total.Name)
+	assert.Equal(t, 2, total.Previous.Sessions)
+	assert.Equal(t, 1400.0, total.Previous.Tonnage)
+	assert.Equal(t, 0.0, total.Previous.TopSet)
+
+	change, ok := percentChange(total.Previous.Tonnage, total.Current.Tonnage)
+	assert.True(t, ok)
+	assert.InDelta(t, -39.3, change, 0.1)
+	_, ok = percentChange(0, 10)
+	assert.False(t, ok)
+}
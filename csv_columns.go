@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// A column that can be listed in a [csv_columns] template in config.toml
+type CSVColumn struct {
+	Header string
+	Value  func(detail UserActivityDetail, e Exercise, location *time.Location) string
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+var csvColumns = map[string]CSVColumn{
+	"performed_at": {"Performed At", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return wallClock(d.PerformedAt, l).Format(time.RFC3339)
+	}},
+	"date": {"Date", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return wallClock(d.PerformedAt, l).Format("2006-01-02")
+	}},
+	"activity_id": {"Activity Id", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return strconv.Itoa(d.Activity.Id)
+	}},
+	"activity_name": {"Activity", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return d.Activity.Name
+	}},
+	"workout_id": {"Workout Id", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return strconv.Itoa(d.FitocracyGroupId)
+	}},
+	"workout_name": {"Workout", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return d.WorkoutName
+	}},
+	"workout_points": {"Workout Points", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return strconv.Itoa(d.WorkoutPoints)
+	}},
+	"points": {"Points", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return strconv.Itoa(d.Points)
+	}},
+	"reps": {"Reps", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return formatFloat(d.Reps)
+	}},
+	"weight": {"Weight", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return formatFloat(d.Weight)
+	}},
+	"units": {"Units", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return d.Units
+	}},
+	"duration": {"Duration (s)", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return formatFloat(d.Duration)
+	}},
+	"distance": {"Distance (m)", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return formatFloat(d.Distance)
+	}},
+	"set": {"Set", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return d.Describe()
+	}},
+	"virtuagym_id": {"VirtuaGym Id", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return strconv.Itoa(e.VirtuaGymId)
+	}},
+	"virtuagym_name": {"VirtuaGym Name", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return e.VirtuaGymName
+	}},
+	"mfp_id": {"MFP Id", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return strconv.Itoa(e.MFPId)
+	}},
+	"mfp_name": {"MFP Name", func(d UserActivityDetail, e Exercise, l *time.Location) string {
+		return e.MFPName
+	}},
+}
+
+// Default layouts, matching the columns these csvs have always had
+var (
+	defaultFitocracyColumns = []string{"performed_at", "activity_id", "activity_name", "weight", "reps"}
+	defaultVirtuaGymColumns = []string{"performed_at", "virtuagym_id", "activity_name", "reps", "weight", "units"}
+)
+
+// Check that every column in a template is one we know how to fill
+func ValidateCSVColumns(names []string) error {
+	for _, name := range names {
+		if _, ok := csvColumns[name]; !ok {
+			return fmt.Errorf("unknown csv column %q", name)
+		}
+	}
+	return nil
+}
+
+func csvHeader(names []string) (header []string) {
+	for _, name := range names {
+		header = append(header, csvColumns[name].Header)
+	}
+	return
+}
+
+func csvRecord(names []string, detail UserActivityDetail, e Exercise, location *time.Location) (record []string) {
+	if location == nil {
+		location = time.UTC
+	}
+	for _, name := range names {
+		record = append(record, csvColumns[name].Value(detail, e, location))
+	}
+	return
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dumpCSV(t *testing.T, dumper CSVDumper, details []UserActivityDetail, mapper *ExerciseMapper) string {
+	var out bytes.Buffer
+	assert.NoError(t, csvExporter{dumper}.Export(&out, details, mapper))
+	return out.String()
+}
+
+func TestFitNotesCSVDumper(t *testing.T) {
+	mapper := NewExerciseMapper([]Exercise{
+		{FitocracyId: 2, FitocracyName: "Barbell Squat", FitNotesName: "Barbell Squat", FitNotesCategory: "Legs"},
+		{FitocracyId: 396, FitocracyName: "Ab Wheel (kneeling)", FitNotesName: "Ab Wheel"}, //no category, so left out
+		{FitocracyId: 518, FitocracyName: "Running", FitNotesName: "Running", FitNotesCategory: "Cardio"},
+	})
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 225, "lb"),
+		testSet(2, 1, 396, 20, 0, "reps"),
+		testSet(3, 1, 518, 1, 30, "min"), //cardio synced without a duration
+	}
+	assert.Equal(t, `Date,Exercise,Category,Weight (kgs),Reps,Distance,Distance Unit,Time,Comment
+2016-01-01,Barbell Squat,Legs,102.06,5,,,,
+2016-01-01,Running,Cardio,0.00,1,,,,
+`, dumpCSV(t, FitNotesCSVDumper{WeightUnits: "kg"}, details, mapper))
+}
+
+func TestJefitCSVDumper(t *testing.T) {
+	mapper := NewExerciseMapper([]Exercise{
+		{FitocracyId: 1, FitocracyName: "Barbell Bench Press", JefitName: "Barbell Bench Press"},
+		{FitocracyId: 2, FitocracyName: "Barbell Squat", JefitName: "Barbell Squat"},
+	})
+	//sets are numbered within each exercise of each workout
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "lb"),
+		testSet(2, 1, 2, 5, 100, "lb"),
+		testSet(3, 1, 1, 5, 80, "lb"),
+		testSet(4, 2, 2, 5, 105, "lb"),
+		testSet(5, 2, 2, 1, 20, "min"), //cardio synced without a duration
+	}
+	assert.Equal(t, `Date,Exercise,Set,Weight,Reps,Units
+2016-01-01,Barbell Squat,1,100.00,5,lbs
+2016-01-01,Barbell Squat,2,100.00,5,lbs
+2016-01-01,Barbell Bench Press,1,80.00,5,lbs
+2016-01-02,Barbell Squat,1,105.00,5,lbs
+2016-01-02,Barbell Squat,2,0.00,1,lbs
+`, dumpCSV(t, &JefitCSVDumper{WeightUnits: "lbs"}, details, mapper))
+}
+
+func TestCSVColumns(t *testing.T) {
+	mapper := NewExerciseMapper([]Exercise{
+		{FitocracyId: 2, FitocracyName: "Barbell Squat", VirtuaGymId: 354, VirtuaGymName: "Squat - Barbell"},
+	})
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 396, 20, 0, "reps"),
+	}
+	details[0].PerformedAt = time.Date(2016, 1, 1, 0, 30, 0, 0, time.UTC)
+	details[1].Activity = &Activity{Id: 396, Name: "Ab Wheel (kneeling)"}
+	details[1].Points = 12
+
+	assert.Equal(t, `Performed At,Activity Id,Activity,Weight,Reps
+2016-01-01T00:30:00Z,2,Barbell Squat,100,5
+2016-01-01T12:00:02Z,396,Ab Wheel (kneeling),0,20
+`, dumpCSV(t, FitocracyCSVDumper{}, details, mapper))
+
+	//times keep the wall clock they were logged at, so the set just after
+	//midnight stays on its day
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	columns := []string{"date", "performed_at", "activity_name", "points", "set"}
+	assert.NoError(t, ValidateCSVColumns(columns))
+	assert.Equal(t, `Date,Performed At,Activity,Points,Set
+2016-01-01,2016-01-01T00:30:00-05:00,Barbell Squat,0,100 kg x 5 reps
+2016-01-01,2016-01-01T12:00:02-05:00,Ab Wheel (kneeling),12,20 reps
+`, dumpCSV(t, FitocracyCSVDumper{Columns: columns, Location: newYork}, details, mapper))
+
+	//unmapped sets are left out of the VirtuaGym csv
+	assert.Equal(t, `Performed At,VirtuaGym Id,Activity,Reps,Weight,Units
+2016-01-01T00:30:00Z,354,Barbell Squat,5,100,kg
+`, dumpCSV(t, VirtuaGymCSVDumper{}, details, mapper))
+
+	assert.Error(t, ValidateCSVColumns([]string{"performed_at", "calories"}))
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"github.com/jmoiron/sqlx"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +19,7 @@ type Activity struct {
 	Id          int       `db:"id"`
 	FitocracyId int       `db:"fitocracy_id"`
 	Name        string    `db:"name"`
+	Multiplier  float64   `db:"multiplier"`
 	CreatedAt   time.Time `db:"created_at"`
 }
 
@@ -34,10 +38,65 @@ type UserActivity struct {
 	Units            string    `db:"units"`
 	Reps             float64   `db:"reps"`
 	Weight           float64   `db:"weight"`
+	Duration         float64   `db:"duration"`
+	Distance         float64   `db:"distance"`
+	IsPR             bool      `db:"is_pr"`
+	Points           int       `db:"points"`
 	PerformedAt      time.Time `db:"performed_at"`
 	CreatedAt        time.Time `db:"created_at"`
 }
 
+// A Fitocracy workout: the group a set of user activities were logged under
+type Workout struct {
+	Id          int       `db:"id"`
+	UserId      int       `db:"user_id"`
+	Name        string    `db:"name"`
+	Points      int       `db:"points"`
+	PerformedAt time.Time `db:"performed_at"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Cardio actions are logged with a time or distance rather than reps and weight
+func (u UserActivity) IsCardio() bool {
+	return u.Duration > 0 || u.Distance > 0
+}
+
+// Strength sets are reps with a weight, or none for bodyweight work. Cardio
+// synced before durations were stored has neither a duration nor a weight
+// unit, so it's neither cardio nor strength.
+func (u UserActivity) IsStrength() bool {
+	return !u.IsCardio() && (u.Weight <= 0 || isWeightUnit(u.Units))
+}
+
+// Formats a set the way Fitocracy's "string" field does, e.g. "135 lb x 5 reps"
+func (u UserActivity) Describe() string {
+	if u.IsCardio() {
+		parts := []string{}
+		if u.Duration > 0 {
+			d := time.Duration(u.Duration) * time.Second
+			parts = append(parts, fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60))
+		}
+		if u.Distance > 0 {
+			parts = append(parts, fmt.Sprintf("%.2f km", u.Distance/1000))
+		}
+		return strings.Join(parts, " | ")
+	}
+	reps := strconv.FormatFloat(u.Reps, 'f', -1, 64) + " reps"
+	if u.Weight <= 0 || !isWeightUnit(u.Units) {
+		return reps
+	}
+	return strconv.FormatFloat(u.Weight, 'f', -1, 64) + " " + u.Units + " x " + reps
+}
+
+// A body weight measurement imported from outside Fitocracy
+type BodyWeight struct {
+	UserId     int       `db:"user_id"`
+	MeasuredAt time.Time `db:"measured_at"`
+	Weight     float64   `db:"weight"`
+	Units      string    `db:"units"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
 // Log API events we perform that actually mutate state, so
 // we have some facility for tracking/undoing them
 type ApiActivityLog struct {
@@ -49,10 +108,104 @@ type ApiActivityLog struct {
 	CreatedAt time.Time `db:"created_at"`
 }
 
-// Used for generating CSVs when you need to join these two tables together
+// Used for generating CSVs when you need to join these two tables together,
+// along with the name and points of the workout the set was part of
 type UserActivityDetail struct {
 	*UserActivity
-	*Activity
+	*Activity     `db:"activity"`
+	WorkoutName   string `db:"workout_name"`
+	WorkoutPoints int    `db:"workout_points"`
+}
+
+// Both tables have id and created_at columns, so the activity columns are
+// aliased to keep them from clobbering the user_activity ones when scanned
+// into a UserActivityDetail. Sets synced before workouts were stored have
+// no workout row, hence the left join.
+const userActivityDetailQuery = `SELECT user_activities.*,
+	activities.id AS "activity.id", activities.name AS "activity.name", activities.multiplier AS "activity.multiplier", activities.created_at AS "activity.created_at",
+	COALESCE(workouts.name, '') AS workout_name, COALESCE(workouts.points, 0) AS workout_points
+	FROM user_activities JOIN activities ON user_activities.activity_id=activities.id
+	LEFT JOIN workouts ON user_activities.fitocracy_group_id=workouts.id
+	WHERE user_activities.user_id=?`
+
+// Narrows down the sets GetUserActivityDetails returns. Zero values match
+// everything; exercises and workouts can be given by id or (case-insensitive) name.
+type ActivityFilter struct {
+	Since     time.Time
+	Until     time.Time
+	Exercises []string
+	Workout   string
+}
+
+// Whether a time falls between the filter's dates
+func (f ActivityFilter) Includes(t time.Time) bool {
+	return (f.Since.IsZero() || !t.Before(f.Since)) && (f.Until.IsZero() || t.Before(f.Until))
+}
+
+// Whether a set would be returned by the filter, the same as where() decides
+// in the db
+func (f ActivityFilter) Matches(detail UserActivityDetail) bool {
+	if !f.Includes(detail.PerformedAt) {
+		return false
+	}
+	if len(f.Exercises) > 0 {
+		matched := false
+		for _, exercise := range f.Exercises {
+			matched = matched || matchesIdOrName(exercise, detail.Activity.Id, detail.Activity.Name)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return f.Workout == "" || matchesIdOrName(f.Workout, detail.FitocracyGroupId, detail.WorkoutName)
+}
+
+func matchesIdOrName(idOrName string, id int, name string) bool {
+	if n, err := strconv.Atoi(idOrName); err == nil {
+		return n == id
+	}
+	return strings.EqualFold(idOrName, name)
+}
+
+// The same exercises on any date and in any workout, for judging the
+// filtered sets against everything that came before them
+func (f ActivityFilter) History() ActivityFilter {
+	f.Since, f.Until, f.Workout = time.Time{}, time.Time{}, ""
+	return f
+}
+
+func (f ActivityFilter) where() (clause string, args []interface{}) {
+	if !f.Since.IsZero() {
+		clause += " AND user_activities.performed_at >= ?"
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		clause += " AND user_activities.performed_at < ?"
+		args = append(args, f.Until)
+	}
+	if len(f.Exercises) > 0 {
+		matches := []string{}
+		for _, exercise := range f.Exercises {
+			if id, err := strconv.Atoi(exercise); err == nil {
+				matches = append(matches, "activities.id = ?")
+				args = append(args, id)
+			} else {
+				matches = append(matches, "LOWER(activities.name) = LOWER(?)")
+				args = append(args, exercise)
+			}
+		}
+		clause += " AND (" + strings.Join(matches, " OR ") + ")"
+	}
+	if f.Workout != "" {
+		if id, err := strconv.Atoi(f.Workout); err == nil {
+			clause += " AND user_activities.fitocracy_group_id = ?"
+			args = append(args, id)
+		} else {
+			clause += " AND LOWER(workouts.name) = LOWER(?)"
+			args = append(args, f.Workout)
+		}
+	}
+	return
 }
 
 var schema = `
@@ -66,6 +219,7 @@ CREATE TABLE IF NOT EXISTS users (
 CREATE TABLE IF NOT EXISTS activities (
     id       	INTEGER PRIMARY KEY,
 	name        TEXT NOT NULL,
+	multiplier  REAL NOT NULL DEFAULT 0,
 	created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -85,10 +239,58 @@ CREATE TABLE IF NOT EXISTS user_activities (
     units        	       TEXT,
     reps        	       DECIMAL(6, 1),
 	weight       	       DECIMAL(6, 1),
+	duration               DECIMAL(10, 1) NOT NULL DEFAULT 0,
+	distance               DECIMAL(10, 1) NOT NULL DEFAULT 0,
+	is_pr                  BOOLEAN NOT NULL DEFAULT 0,
+	points                 INTEGER NOT NULL DEFAULT 0,
 	performed_at  	       TIMESTAMP NOT NULL,
 	created_at             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS workouts (
+    id                 INTEGER PRIMARY KEY,
+    user_id            INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name               TEXT NOT NULL DEFAULT '',
+    points             INTEGER NOT NULL DEFAULT 0,
+	performed_at       TIMESTAMP NOT NULL,
+	created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS exercise_mappings (
+    user_id             INT NOT NULL DEFAULT 0,
+    fitocracy_id        INT NOT NULL,
+    fitocracy_name      TEXT NOT NULL DEFAULT '',
+    mfp_name            TEXT NOT NULL DEFAULT '',
+    mfp_id              INT NOT NULL DEFAULT 0,
+    mfp_type            TEXT NOT NULL DEFAULT '',
+    virtuagym_name      TEXT NOT NULL DEFAULT '',
+    virtuagym_id        INT NOT NULL DEFAULT 0,
+    fitnotes_name       TEXT NOT NULL DEFAULT '',
+    fitnotes_category   TEXT NOT NULL DEFAULT '',
+    jefit_name          TEXT NOT NULL DEFAULT '',
+    garmin_category     TEXT NOT NULL DEFAULT '',
+    garmin_subcategory  INT NULL,
+    tcx_sport           TEXT NOT NULL DEFAULT '',
+    apple_activity_type TEXT NOT NULL DEFAULT '',
+    strong_name         TEXT NOT NULL DEFAULT '',
+    primary_muscles     TEXT NOT NULL DEFAULT '',
+    secondary_muscles   TEXT NOT NULL DEFAULT '',
+    equipment           TEXT NOT NULL DEFAULT '',
+    movement_pattern    TEXT NOT NULL DEFAULT '',
+    bodyweight          BOOLEAN NOT NULL DEFAULT 0,
+	created_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(user_id, fitocracy_id)
+);
+
+CREATE TABLE IF NOT EXISTS body_weights (
+    user_id            INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    measured_at        TIMESTAMP NOT NULL,
+    weight             DECIMAL(6, 1) NOT NULL,
+    units              TEXT NOT NULL,
+	created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(user_id, measured_at)
+);
+
 CREATE TABLE IF NOT EXISTS api_activity_log (
     id                 INTEGER PRIMARY KEY,
     operation          TEXT NOT NULL,	
@@ -102,8 +304,31 @@ func getDB() (db *sqlx.DB, err error) {
 	return sqlx.Connect("sqlite3", "./fitocracy.db")
 }
 
+// Columns added since the original schema. CREATE TABLE IF NOT EXISTS won't
+// add them to an existing db, so they're applied one at a time and the
+// "duplicate column" error from a db that already has them is ignored.
+var migrations = []string{
+	"ALTER TABLE user_activities ADD COLUMN duration DECIMAL(10, 1) NOT NULL DEFAULT 0",
+	"ALTER TABLE user_activities ADD COLUMN distance DECIMAL(10, 1) NOT NULL DEFAULT 0",
+	"ALTER TABLE exercise_mappings ADD COLUMN primary_muscles TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE exercise_mappings ADD COLUMN secondary_muscles TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE exercise_mappings ADD COLUMN equipment TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE exercise_mappings ADD COLUMN movement_pattern TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE exercise_mappings ADD COLUMN bodyweight BOOLEAN NOT NULL DEFAULT 0",
+	"ALTER TABLE user_activities ADD COLUMN is_pr BOOLEAN NOT NULL DEFAULT 0",
+	"ALTER TABLE user_activities ADD COLUMN points INTEGER NOT NULL DEFAULT 0",
+	"ALTER TABLE activities ADD COLUMN multiplier REAL NOT NULL DEFAULT 0",
+	"ALTER TABLE exercise_mappings ADD COLUMN mfp_type TEXT NOT NULL DEFAULT ''",
+}
+
 func ensureSchema(db *sqlx.DB) {
 	db.MustExec(schema)
+	for _, migration := range migrations {
+		_, err := db.Exec(migration)
+		if nil != err && !strings.Contains(err.Error(), "duplicate column") {
+			panic(err)
+		}
+	}
 }
 
 func GetUserByFitocracyId(db *sqlx.DB, fitocracyUserId int) (err error, user User) {
@@ -115,3 +340,104 @@ func GetUserByUsername(db *sqlx.DB, fitocracyUsername string) (err error, user U
 	err = db.Get(&user, "SELECT * FROM users WHERE fitocracy_username=$1", fitocracyUsername)
 	return
 }
+
+// Every set a user has performed that matches the filter, joined to its
+// activity, in the order performed
+func GetUserActivityDetails(db *sqlx.DB, userId int, filter ActivityFilter) (err error, details []UserActivityDetail) {
+	where, args := filter.where()
+	query := userActivityDetailQuery + where + " ORDER BY user_activities.performed_at, user_activities.id"
+	err = db.Select(&details, query, append([]interface{}{userId}, args...)...)
+	return
+}
+
+// Mapping rows with user_id 0 are the shared ones from exercise_mappings.toml;
+// any other user_id is that user's override of the shared row
+const sharedMappingsUserId = 0
+
+var insertExerciseMapping = `INSERT OR REPLACE INTO exercise_mappings
+	(user_id, fitocracy_id, fitocracy_name, mfp_name, mfp_id, mfp_type, virtuagym_name, virtuagym_id, fitnotes_name,
+	 fitnotes_category, jefit_name, garmin_category, garmin_subcategory, tcx_sport, apple_activity_type, strong_name,
+	 primary_muscles, secondary_muscles, equipment, movement_pattern, bodyweight)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func saveExerciseMappings(tx *sqlx.Tx, userId int, exercises []Exercise) error {
+	for _, e := range exercises {
+		_, err := tx.Exec(insertExerciseMapping, userId, e.FitocracyId, e.FitocracyName, e.MFPName, e.MFPId, e.MFPType,
+			e.VirtuaGymName, e.VirtuaGymId, e.FitNotesName, e.FitNotesCategory, e.JefitName, e.GarminCategory,
+			e.GarminSubcategory, e.TCXSport, e.AppleActivityType, e.StrongName, e.PrimaryMuscles, e.SecondaryMuscles,
+			e.Equipment, e.MovementPattern, e.Bodyweight)
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replace the shared mappings with the mapping file's. Users' overrides are
+// left alone.
+func SyncExerciseMappings(db *sqlx.DB, exercises []Exercise) error {
+	return SaveExerciseMappings(db, sharedMappingsUserId, exercises, true)
+}
+
+// Store mappings for the user, overriding the shared mappings for the same
+// Fitocracy exercises. With replace, the user's other overrides are dropped.
+func SaveExerciseMappings(db *sqlx.DB, userId int, exercises []Exercise, replace bool) error {
+	tx, err := db.Beginx()
+	if nil != err {
+		return err
+	}
+	if replace {
+		if _, err = tx.Exec("DELETE FROM exercise_mappings WHERE user_id=?", userId); nil != err {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = saveExerciseMappings(tx, userId, exercises); nil != err {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+var exerciseMappingColumns = `fitocracy_id, fitocracy_name, mfp_name, mfp_id, mfp_type, virtuagym_name, virtuagym_id, fitnotes_name,
+	fitnotes_category, jefit_name, garmin_category, garmin_subcategory, tcx_sport, apple_activity_type, strong_name,
+	primary_muscles, secondary_muscles, equipment, movement_pattern, bodyweight`
+
+// The mappings that apply to the user: their overrides, and the shared
+// mappings for everything else
+func GetExerciseMappings(db *sqlx.DB, userId int) (err error, exercises []Exercise) {
+	query := "SELECT " + exerciseMappingColumns + ` FROM exercise_mappings
+		WHERE user_id=? OR (user_id=? AND fitocracy_id NOT IN (SELECT fitocracy_id FROM exercise_mappings WHERE user_id=?))
+		ORDER BY fitocracy_id`
+	err = db.Select(&exercises, query, userId, sharedMappingsUserId, userId)
+	return
+}
+
+// Only the user's overrides of the shared mappings
+func GetExerciseMappingOverrides(db *sqlx.DB, userId int) (err error, exercises []Exercise) {
+	query := "SELECT " + exerciseMappingColumns + " FROM exercise_mappings WHERE user_id=? ORDER BY fitocracy_id"
+	err = db.Select(&exercises, query, userId)
+	return
+}
+
+// Store body weights for the user, replacing any measured at the same time
+func SaveBodyWeights(db *sqlx.DB, userId int, weights []BodyWeight) error {
+	tx, err := db.Beginx()
+	if nil != err {
+		return err
+	}
+	for _, w := range weights {
+		_, err = tx.Exec("INSERT OR REPLACE INTO body_weights(user_id, measured_at, weight, units) VALUES(?, ?, ?, ?)",
+			userId, w.MeasuredAt, w.Weight, w.Units)
+		if nil != err {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func GetBodyWeights(db *sqlx.DB, userId int) (err error, weights []BodyWeight) {
+	err = db.Select(&weights, "SELECT * FROM body_weights WHERE user_id=? ORDER BY measured_at", userId)
+	return
+}
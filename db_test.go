@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExerciseMappingOverrides(t *testing.T) {
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1) //every connection would get its own in-memory db
+	ensureSchema(db)
+
+	squat := Exercise{FitocracyId: 2, FitocracyName: "Barbell Squat", VirtuaGymId: 354, VirtuaGymName: "Squat - Barbell",
+		PrimaryMuscles: MuscleList{"quads", "glutes"}, Equipment: "barbell", MovementPattern: "squat"}
+	bench := Exercise{FitocracyId: 1, FitocracyName: "Barbell Bench Press", MFPId: 219, MFPName: "Bench Press, Barbell"}
+	assert.NoError(t, SyncExerciseMappings(db, []Exercise{squat, bench}))
+
+	myBench := Exercise{FitocracyId: 1, FitocracyName: "Barbell Bench Press", StrongName: "Bench Press (Barbell)", Bodyweight: true}
+	assert.NoError(t, SaveExerciseMappings(db, 7, []Exercise{myBench}, false))
+
+	//syncing the file again keeps the override
+	assert.NoError(t, SyncExerciseMappings(db, []Exercise{squat, bench}))
+
+	err, mine := GetExerciseMappings(db, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []Exercise{myBench, squat}, mine)
+
+	err, others := GetExerciseMappings(db, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []Exercise{bench, squat}, others)
+
+	err, overrides := GetExerciseMappingOverrides(db, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []Exercise{myBench}, overrides)
+}
+
+func TestEncodeMappingsRoundTrips(t *testing.T) {
+	subcategory := 3
+	exercises := []Exercise{
+		{FitocracyId: 1, FitocracyName: "Barbell Bench Press", MFPId: 219, MFPName: "Bench Press, Barbell"},
+		{FitocracyId: 2, FitocracyName: "Barbell Squat", GarminCategory: "squat", GarminSubcategory: &subcategory,
+			PrimaryMuscles: MuscleList{"quads", "glutes"}, SecondaryMuscles: MuscleList{"hamstrings"}},
+		{FitocracyId: 1160, FitocracyName: "Push-Up", MovementPattern: "horizontal_push", Bodyweight: true},
+	}
+	encoded, err := EncodeMappings(exercises)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "virtuagym_id")
+
+	var decoded MappingFile
+	_, err = toml.Decode(string(encoded), &decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, exercises, decoded.Exercises)
+}
+
+// Every key in the shipped mapping file has to survive being stored and
+// exported again, or mappings export | import loses it
+func TestShippedMappingsRoundTripThroughDB(t *testing.T) {
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1)
+	ensureSchema(db)
+
+	exercises, err := LoadMappingFile("exercise_mappings.toml")
+	assert.NoError(t, err)
+	assert.NoError(t, SaveExerciseMappings(db, 7, exercises, true))
+	err, stored := GetExerciseMappings(db, 7)
+	assert.NoError(t, err)
+	encoded, err := EncodeMappings(stored)
+	assert.NoError(t, err)
+
+	//compare as plain TOML, entry by entry, so a key the structs don't know fails too
+	entries := func(file map[string][]map[string]interface{}) map[int64]map[string]interface{} {
+		byId := map[int64]map[string]interface{}{}
+		for _, entry := range file["exercises"] {
+			byId[entry["fitocracy_id"].(int64)] = entry
+		}
+		return byId
+	}
+	var shipped, exported map[string][]map[string]interface{}
+	_, err = toml.DecodeFile("exercise_mappings.toml", &shipped)
+	assert.NoError(t, err)
+	_, err = toml.Decode(string(encoded), &exported)
+	assert.NoError(t, err)
+	assert.Equal(t, entries(shipped), entries(exported))
+}
+
+func TestActivityFilterWhere(t *testing.T) {
+	since := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter ActivityFilter
+		clause string
+		args   []interface{}
+	}{
+		{"everything", ActivityFilter{}, "", nil},
+		{"since", ActivityFilter{Since: since},
+			" AND user_activities.performed_at >= ?", []interface{}{since}},
+		{"until", ActivityFilter{Until: until},
+			" AND user_activities.performed_at < ?", []interface{}{until}},
+		{"exercise by id", ActivityFilter{Exercises: []string{"2"}},
+			" AND (activities.id = ?)", []interface{}{2}},
+		{"exercise by name", ActivityFilter{Exercises: []string{"Barbell Squat"}},
+			" AND (LOWER(activities.name) = LOWER(?))", []interface{}{"Barbell Squat"}},
+		{"workout by id", ActivityFilter{Workout: "45255911"},
+			" AND user_activities.fitocracy_group_id = ?", []interface{}{45255911}},
+		{"workout by name", ActivityFilter{Workout: "Workout A"},
+			" AND LOWER(workouts.name) = LOWER(?)", []interface{}{"Workout A"}},
+		{"combined", ActivityFilter{Since: since, Until: until, Exercises: []string{"1", "Barbell Squat"}, Workout: "Workout A"},
+			" AND user_activities.performed_at >= ? AND user_activities.performed_at < ?" +
+				" AND (activities.id = ? OR LOWER(activities.name) = LOWER(?)) AND LOWER(workouts.name) = LOWER(?)",
+			[]interface{}{since, until, 1, "Barbell Squat", "Workout A"}},
+	}
+
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1)
+	ensureSchema(db)
+	for _, test := range tests {
+		clause, args := test.filter.where()
+		assert.Equal(t, test.clause, clause, test.name)
+		assert.Equal(t, test.args, args, test.name)
+		//and it's SQL the detail query accepts
+		err, _ := GetUserActivityDetails(db, 1, test.filter)
+		assert.NoError(t, err, test.name)
+	}
+}
+
+func TestGetUserActivityDetailsFiltered(t *testing.T) {
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1)
+	ensureSchema(db)
+	db.MustExec("INSERT INTO activities (id, name) VALUES (1, 'Barbell Squat'), (2, 'Barbell Bench Press')")
+	day := func(d, hour int) time.Time { return time.Date(2016, 1, d, hour, 0, 0, 0, time.UTC) }
+	db.MustExec("INSERT INTO workouts (id, user_id, name, performed_at) VALUES (10, 1, 'Workout A', ?), (11, 1, 'Workout B', ?)", day(30, 18), day(31, 23))
+	//set 5's workout was never synced, but the LEFT JOIN still returns it; set 4 is another user's
+	for _, set := range []struct {
+		id, group, user, activity int
+		at                        time.Time
+	}{
+		{1, 10, 1, 1, day(30, 18)},
+		{2, 10, 1, 2, day(30, 18)},
+		{3, 11, 1, 1, day(31, 23)},
+		{4, 12, 2, 1, day(31, 12)},
+		{5, 13, 1, 1, time.Date(2016, 2, 1, 9, 0, 0, 0, time.UTC)},
+	} {
+		db.MustExec("INSERT INTO user_activities (id, fitocracy_group_id, user_id, activity_id, units, reps, weight, performed_at) VALUES (?, ?, ?, ?, 'kg', 5, 100, ?)",
+			set.id, set.group, set.user, set.activity, set.at)
+	}
+
+	tests := []struct {
+		args []string
+		ids  []int
+	}{
+		{nil, []int{1, 2, 3, 5}},
+		{[]string{"--until=2016-01-31"}, []int{1, 2, 3}},
+		{[]string{"--since=2016-01-31"}, []int{3, 5}},
+		{[]string{"--exercise=barbell SQUAT"}, []int{1, 3, 5}},
+		{[]string{"--exercise=2", "--exercise=Barbell Squat", "--until=2016-01-30"}, []int{1, 2}},
+		{[]string{"--workout=workout b"}, []int{3}},
+		{[]string{"--workout=10", "--exercise=barbell bench press"}, []int{2}},
+		{[]string{"--workout=13"}, []int{5}},
+	}
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		filterFlags := addFilterFlags(flags)
+		assert.NoError(t, flags.Parse(test.args))
+		filter, err := filterFlags()
+		assert.NoError(t, err)
+
+		err, details := GetUserActivityDetails(db, 1, filter)
+		assert.NoError(t, err, "%v", test.args)
+		ids := []int{}
+		for _, detail := range details {
+			ids = append(ids, detail.UserActivity.Id)
+		}
+		assert.Equal(t, test.ids, ids, "%v", test.args)
+	}
+}
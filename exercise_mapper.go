@@ -1,20 +1,42 @@
 package main
 
-import "github.com/cloudflare/cfssl/log"
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+)
 
 type Exercise struct {
-	FitocracyName string `toml:"fitocracy_name"`
-	FitocracyId   int    `toml:"fitocracy_id"`
-	MFPName       string `toml:"mfp_name"`
-	MFPId         int    `toml:"mfp_id"`
-	VirtuaGymName string `toml:"virtuagym_name"`
-	VirtuaGymId   int    `toml:"virtuagym_id"`
+	FitocracyName     string `toml:"fitocracy_name" db:"fitocracy_name"`
+	FitocracyId       int    `toml:"fitocracy_id" db:"fitocracy_id"`
+	MFPName           string `toml:"mfp_name,omitempty" db:"mfp_name"`
+	MFPId             int    `toml:"mfp_id,omitzero" db:"mfp_id"`
+	MFPType           string `toml:"mfp_type,omitempty" db:"mfp_type"`
+	VirtuaGymName     string `toml:"virtuagym_name,omitempty" db:"virtuagym_name"`
+	VirtuaGymId       int    `toml:"virtuagym_id,omitzero" db:"virtuagym_id"`
+	FitNotesName      string `toml:"fitnotes_name,omitempty" db:"fitnotes_name"`
+	FitNotesCategory  string `toml:"fitnotes_category,omitempty" db:"fitnotes_category"`
+	JefitName         string `toml:"jefit_name,omitempty" db:"jefit_name"`
+	GarminCategory    string `toml:"garmin_category,omitempty" db:"garmin_category"`
+	GarminSubcategory *int   `toml:"garmin_subcategory,omitempty" db:"garmin_subcategory"`
+	TCXSport          string `toml:"tcx_sport,omitempty" db:"tcx_sport"`
+	AppleActivityType string `toml:"apple_activity_type,omitempty" db:"apple_activity_type"`
+	StrongName        string `toml:"strong_name,omitempty" db:"strong_name"`
+
+	// Optional metadata, for reports by muscle group and exporters that need
+	// categories. See exercise_metadata.go for the allowed values.
+	PrimaryMuscles   MuscleList `toml:"primary_muscles,omitempty" db:"primary_muscles"`
+	SecondaryMuscles MuscleList `toml:"secondary_muscles,omitempty" db:"secondary_muscles"`
+	Equipment        string     `toml:"equipment,omitempty" db:"equipment"`
+	MovementPattern  string     `toml:"movement_pattern,omitempty" db:"movement_pattern"`
+	Bodyweight       bool       `toml:"bodyweight,omitempty" db:"bodyweight"`
 }
 
 type ExerciseMapper struct {
-	exercises []Exercise
+	exercises     []Exercise
 	ByFitocracyId map[int]Exercise
 	ByVirtuaGymId map[int]Exercise
+	ByMFPId       map[int]Exercise
 }
 
 /**
@@ -23,17 +45,60 @@ type ExerciseMapper struct {
 func NewExerciseMapper(exercises []Exercise) *ExerciseMapper {
 	mapper := new(ExerciseMapper)
 	mapper.exercises = exercises
-	mapper.ByFitocracyId =  make(map[int]Exercise)
-	mapper.ByVirtuaGymId =  make(map[int]Exercise)
+	mapper.ByFitocracyId = make(map[int]Exercise)
+	mapper.ByVirtuaGymId = make(map[int]Exercise)
+	mapper.ByMFPId = make(map[int]Exercise)
 
 	for _, e := range exercises {
 		mapper.ByFitocracyId[e.FitocracyId] = e
 		if e.VirtuaGymId > 0 {
 			mapper.ByVirtuaGymId[e.VirtuaGymId] = e
-		} else {
-			log.Warningf("No virtuagym mapping for %d - %s", e.FitocracyId, e)
+		}
+		if e.MFPId > 0 {
+			mapper.ByMFPId[e.MFPId] = e
 		}
 	}
 
 	return mapper
-}
\ No newline at end of file
+}
+
+// How to tell whether an exercise has a mapping for each app we export to
+var mappingTargets = map[string]func(Exercise) bool{
+	"virtuagym": func(e Exercise) bool { return e.VirtuaGymId > 0 },
+	"mfp":       func(e Exercise) bool { return e.MFPId > 0 },
+	"fitnotes":  func(e Exercise) bool { return e.FitNotesName != "" && e.FitNotesCategory != "" },
+	"jefit":     func(e Exercise) bool { return e.JefitName != "" },
+	"garmin":    func(e Exercise) bool { return e.garminCategory() != "" },
+	"tcx":       func(e Exercise) bool { return e.TCXSport != "" },
+	"apple":     func(e Exercise) bool { return e.appleActivityType() != "" },
+	"strong":    func(e Exercise) bool { return e.StrongName != "" },
+}
+
+// Whether the Fitocracy activity has a mapping for the target app. Unknown
+// targets have nothing to map, so everything counts as mapped.
+func (m *ExerciseMapper) IsMapped(target string, fitocracyId int) bool {
+	isMapped, ok := mappingTargets[target]
+	if !ok {
+		return true
+	}
+	return isMapped(m.ByFitocracyId[fitocracyId])
+}
+
+// The layout of exercise_mappings.toml
+type MappingFile struct {
+	Exercises []Exercise `toml:"exercises"`
+}
+
+func LoadMappingFile(filename string) ([]Exercise, error) {
+	var mappingFile MappingFile
+	_, err := toml.DecodeFile(filename, &mappingFile)
+	return mappingFile.Exercises, err
+}
+
+// Mappings in the same format as exercise_mappings.toml, leaving out
+// anything that isn't mapped
+func EncodeMappings(exercises []Exercise) ([]byte, error) {
+	var buf bytes.Buffer
+	err := toml.NewEncoder(&buf).Encode(MappingFile{exercises})
+	return buf.Bytes(), err
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// The values the metadata fields in exercise_mappings.toml may use
+var (
+	muscleGroups = []string{
+		"abs", "obliques", "lower_back", "chest", "lats", "upper_back", "traps", "shoulders", "biceps",
+		"triceps", "forearms", "quads", "hamstrings", "glutes", "calves", "adductors", "hip_flexors", "neck",
+	}
+	equipmentTypes = []string{
+		"barbell", "dumbbell", "kettlebell", "cable", "machine", "smith_machine", "bodyweight", "band",
+		"stability_ball", "ab_wheel", "other",
+	}
+	movementPatterns = []string{
+		"horizontal_push", "vertical_push", "horizontal_pull", "vertical_pull", "squat", "hinge", "lunge",
+		"carry", "rotation", "core", "isolation", "olympic", "cardio",
+	}
+)
+
+func isOneOf(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// A list of muscle groups, stored in the db as a comma separated string
+type MuscleList []string
+
+func (m MuscleList) Value() (driver.Value, error) {
+	return strings.Join(m, ","), nil
+}
+
+func (m *MuscleList) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("can't scan %T into a MuscleList", src)
+	}
+	*m = nil
+	for _, muscle := range strings.Split(s, ",") {
+		if muscle != "" {
+			*m = append(*m, muscle)
+		}
+	}
+	return nil
+}
+
+// FIT exercise categories implied by an exercise's metadata, for exercises
+// without a garmin_category. Isolation work is categorized by the muscle.
+var (
+	garminCategoriesByPattern = map[string]string{
+		"horizontal_push": "bench_press",
+		"vertical_push":   "shoulder_press",
+		"horizontal_pull": "row",
+		"vertical_pull":   "pull_up",
+		"squat":           "squat",
+		"hinge":           "deadlift",
+		"lunge":           "lunge",
+		"carry":           "carry",
+		"rotation":        "core",
+		"core":            "core",
+		"olympic":         "olympic_lift",
+		"cardio":          "cardio",
+	}
+	garminCategoriesByMuscle = map[string]string{
+		"chest":      "flye",
+		"shoulders":  "lateral_raise",
+		"traps":      "shrug",
+		"biceps":     "curl",
+		"forearms":   "curl",
+		"triceps":    "triceps_extension",
+		"quads":      "squat",
+		"hamstrings": "leg_curl",
+		"calves":     "calf_raise",
+		"abs":        "crunch",
+		"obliques":   "core",
+		"lower_back": "hyperextension",
+	}
+)
+
+// The exercise's garmin_category, or failing that one implied by its
+// metadata
+func (e Exercise) garminCategory() string {
+	if e.GarminCategory != "" {
+		return e.GarminCategory
+	}
+	if e.MovementPattern == "horizontal_push" && e.Bodyweight {
+		return "push_up"
+	}
+	if category, ok := garminCategoriesByPattern[e.MovementPattern]; ok {
+		return category
+	}
+	if e.MovementPattern == "isolation" && len(e.PrimaryMuscles) > 0 {
+		return garminCategoriesByMuscle[e.PrimaryMuscles[0]]
+	}
+	return ""
+}
+
+// The exercise's apple_activity_type, or failing that one implied by its
+// metadata. Barbell and machine work is left to the strength default.
+func (e Exercise) appleActivityType() string {
+	if e.AppleActivityType != "" {
+		return e.AppleActivityType
+	}
+	switch {
+	case e.MovementPattern == "core" || e.MovementPattern == "rotation":
+		return "CoreTraining"
+	case e.Bodyweight || e.Equipment == "kettlebell" || e.Equipment == "band":
+		return "FunctionalStrengthTraining"
+	}
+	return ""
+}
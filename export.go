@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/viper"
+)
+
+// Exporters need to see a user's whole history at once (to group sets into
+// workouts, for example) rather than one row at a time
+type Exporter interface {
+	Export(io.Writer, []UserActivityDetail, *ExerciseMapper) error
+	FileExtension() string
+}
+
+// Exporters (or the CSVDumpers they wrap) that leave out exercises without a
+// mapping for their app say which mapping they need, so they can be reported
+type MappedExporter interface {
+	MappingTarget() string
+}
+
+// The timezone set in config.toml, which times are reported in
+func configuredLocation() *time.Location {
+	location, err := time.LoadLocation(viper.GetString("timezone"))
+	if err != nil {
+		log.Fatalf("Invalid timezone %s: %s\n", viper.GetString("timezone"), err)
+	}
+	return location
+}
+
+// Fitocracy times are the wall clock a set was logged at, stored without a
+// zone and read back as UTC. This keeps the clock reading and puts it in the
+// location, where converting with In would shift it by the zone's offset.
+func wallClock(t time.Time, location *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
+}
+
+// The fitocracy and virtuagym csv dumpers, laid out per the [csv_columns]
+// templates and timezone in config.toml
+func configuredCSVDumpers() (FitocracyCSVDumper, VirtuaGymCSVDumper) {
+	location := configuredLocation()
+	fitocracyColumns := viper.GetStringSlice("csv_columns.fitocracy")
+	virtuaGymColumns := viper.GetStringSlice("csv_columns.virtuagym")
+	for _, columns := range [][]string{fitocracyColumns, virtuaGymColumns} {
+		if err := ValidateCSVColumns(columns); err != nil {
+			log.Fatal("Invalid csv_columns in config: ", err)
+		}
+	}
+	return FitocracyCSVDumper{Columns: fitocracyColumns, Location: location},
+		VirtuaGymCSVDumper{Columns: virtuaGymColumns, Location: location}
+}
+
+// The formats the export command can target, by name
+func exporters() map[string]Exporter {
+	weightUnits := viper.GetString("weight_units")
+	fitocracyDumper, virtuaGymDumper := configuredCSVDumpers()
+	return map[string]Exporter{
+		"fitocracy": csvExporter{fitocracyDumper},
+		"virtuagym": csvExporter{virtuaGymDumper},
+		"fitnotes":  csvExporter{FitNotesCSVDumper{WeightUnits: weightUnits}},
+		"jefit":     csvExporter{&JefitCSVDumper{WeightUnits: weightUnits}},
+		"mfp":       MFPExporter{WeightUnits: weightUnits},
+		"garmin":    GarminFITExporter{},
+		"tcx":       TCXExporter{},
+		"xlsx":      XLSXExporter{WeightUnits: weightUnits},
+		"markdown":  MarkdownJournalExporter{},
+		"html":      HTMLJournalExporter{},
+		"ical":      ICalExporter{Location: configuredLocation()},
+		"apple":     AppleHealthExporter{BodyWeightKg: ConvertWeight(viper.GetFloat64("body_weight"), weightUnits, "kg")},
+	}
+}
+
+// Handles "export -target=<format> [-out=<file>]"
+func runExport(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	available := exporters()
+	targets := []string{}
+	for name := range available {
+		targets = append(targets, name)
+	}
+	sort.Strings(targets)
+
+	exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
+	target := exportFlags.String("target", "", "Format to export: "+strings.Join(targets, ", "))
+	out := exportFlags.String("out", "", "File to write (defaults to <target>.<extension>)")
+	strict := exportFlags.Bool("strict", false, "Fail instead of warning when performed exercises have no mapping for the target")
+	filterFlags := addFilterFlags(exportFlags)
+	exportFlags.Parse(args)
+
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+
+	exporter, ok := available[*target]
+	if !ok {
+		exportFlags.PrintDefaults()
+		return fmt.Errorf("unknown export target %q", *target)
+	}
+	if "" == *out {
+		*out = *target + "." + exporter.FileExtension()
+	}
+	return ExportFile(db, username, *out, exerciseMapper, exporter, filter, *strict)
+}
+
+// Export the contents of an already populated db into a file. Exercises the
+// exporter can't include for lack of a mapping are logged once each, or fail
+// the export before anything is written when strict is set.
+func ExportFile(db *sqlx.DB, username string, filename string, exerciseMapper *ExerciseMapper, exporter Exporter, filter ActivityFilter, strict bool) (err error) {
+	err, user := GetUserByUsername(db, username)
+	if nil != err {
+		return err
+	}
+
+	err, details := GetUserActivityDetails(db, user.Id, filter)
+	if nil != err {
+		return err
+	}
+
+	if mapped, ok := exporter.(MappedExporter); ok && mapped.MappingTarget() != "" {
+		target := mapped.MappingTarget()
+		unmapped := UnmappedActivities(details, exerciseMapper, target)
+		sets := 0
+		for _, activity := range unmapped {
+			log.Printf("No %s mapping for [%d] %s (%d sets)\n", target, activity.Id, activity.Name, activity.Sets)
+			sets += activity.Sets
+		}
+		if len(unmapped) > 0 {
+			if strict {
+				return fmt.Errorf("%d exercises (%d sets) have no %s mapping", len(unmapped), sets, target)
+			}
+			log.Printf("%d exercises (%d sets) have no %s mapping\n", len(unmapped), sets, target)
+		}
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0777)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = exporter.Export(file, details, exerciseMapper)
+	if nil != err {
+		return err
+	}
+	log.Printf("Wrote %s output to %s\n", exporter.FileExtension(), filename)
+	return
+}
+
+// The sets that make up one Fitocracy workout, in the order performed
+type WorkoutGroup struct {
+	Id      int
+	Details []UserActivityDetail
+}
+
+func (g WorkoutGroup) Start() time.Time {
+	return g.Details[0].PerformedAt
+}
+
+func (g WorkoutGroup) End() time.Time {
+	return g.Details[len(g.Details)-1].PerformedAt
+}
+
+// Fitocracy often stamps every set in a workout with the same time, so when
+// there's no spread between the first and last set assume a minute per set
+const assumedSetDuration = time.Minute
+
+func (g WorkoutGroup) Duration() time.Duration {
+	if d := g.End().Sub(g.Start()); d > 0 {
+		return d
+	}
+	return time.Duration(len(g.Details)) * assumedSetDuration
+}
+
+// Split a user's sets into the workouts they were logged in, ordered by when
+// each workout started
+func GroupWorkouts(details []UserActivityDetail) (groups []*WorkoutGroup) {
+	byId := map[int]*WorkoutGroup{}
+	for _, detail := range details {
+		group, ok := byId[detail.FitocracyGroupId]
+		if !ok {
+			group = &WorkoutGroup{Id: detail.FitocracyGroupId}
+			byId[detail.FitocracyGroupId] = group
+			groups = append(groups, group)
+		}
+		group.Details = append(group.Details, detail)
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A flag that can be given more than once, collecting every value
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Dates on the command line are whole days; --until includes the day given
+const filterDateFormat = "2006-01-02"
+
+// Register --since, --until, --exercise and --workout on a command's flags.
+// Call the returned function after parsing to get the resulting filter.
+func addFilterFlags(flags *flag.FlagSet) func() (ActivityFilter, error) {
+	since := flags.String("since", "", "Only include sets performed on or after this date (YYYY-MM-DD)")
+	until := flags.String("until", "", "Only include sets performed on or before this date (YYYY-MM-DD)")
+	exercises := &stringList{}
+	flags.Var(exercises, "exercise", "Only include this exercise, by Fitocracy id or name (repeatable)")
+	workout := flags.String("workout", "", "Only include sets from this workout, by Fitocracy id or name")
+
+	return func() (filter ActivityFilter, err error) {
+		if *since != "" {
+			filter.Since, err = time.Parse(filterDateFormat, *since)
+			if err != nil {
+				return filter, fmt.Errorf("invalid --since date: %s", err)
+			}
+		}
+		if *until != "" {
+			filter.Until, err = time.Parse(filterDateFormat, *until)
+			if err != nil {
+				return filter, fmt.Errorf("invalid --until date: %s", err)
+			}
+			filter.Until = filter.Until.AddDate(0, 0, 1)
+		}
+		filter.Exercises = *exercises
+		filter.Workout = *workout
+		return
+	}
+}
@@ -0,0 +1,40 @@
+package fit
+
+// The FIT exercise_category enum, by the names used in the FIT profile. These
+// are what exercise_mappings.toml refers to in garmin_category.
+var ExerciseCategories = map[string]uint16{
+	"bench_press":        0,
+	"calf_raise":         1,
+	"cardio":             2,
+	"carry":              3,
+	"chop":               4,
+	"core":               5,
+	"crunch":             6,
+	"curl":               7,
+	"deadlift":           8,
+	"flye":               9,
+	"hip_raise":          10,
+	"hip_stability":      11,
+	"hip_swing":          12,
+	"hyperextension":     13,
+	"lateral_raise":      14,
+	"leg_curl":           15,
+	"leg_raise":          16,
+	"lunge":              17,
+	"olympic_lift":       18,
+	"plank":              19,
+	"plyo":               20,
+	"pull_up":            21,
+	"push_up":            22,
+	"row":                23,
+	"shoulder_press":     24,
+	"shoulder_stability": 25,
+	"shrug":              26,
+	"sit_up":             27,
+	"squat":              28,
+	"total_body":         29,
+	"triceps_extension":  30,
+	"warm_up":            31,
+	"run":                32,
+	"unknown":            65534,
+}
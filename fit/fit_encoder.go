@@ -0,0 +1,189 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Just enough of the Garmin FIT protocol to write activity files: every
+// message is written as a definition followed by its data, little endian.
+// See the FIT SDK's Profile.xlsx for the message and field numbers used here.
+
+const (
+	protocolVersion = 0x20 // 2.0
+	profileVersion  = 2132 // 21.32
+	headerSize      = 14
+)
+
+// Base types, as they appear in a field definition
+type BaseType uint8
+
+const (
+	Enum    BaseType = 0x00
+	Uint8   BaseType = 0x02
+	Uint16  BaseType = 0x84
+	Uint32  BaseType = 0x86
+	Uint32z BaseType = 0x8C
+)
+
+func (b BaseType) size() int {
+	switch b {
+	case Uint16:
+		return 2
+	case Uint32, Uint32z:
+		return 4
+	}
+	return 1
+}
+
+// Global message numbers
+const (
+	MesgFileId   uint16 = 0
+	MesgSession  uint16 = 18
+	MesgLap      uint16 = 19
+	MesgEvent    uint16 = 21
+	MesgActivity uint16 = 34
+	MesgSet      uint16 = 225
+)
+
+// A single field of a message. Value must be an unsigned integer, or a
+// []uint16 for array fields like a set's exercise category.
+type Field struct {
+	Num   uint8
+	Type  BaseType
+	Value interface{}
+}
+
+func NewField(num uint8, t BaseType, value interface{}) Field {
+	return Field{Num: num, Type: t, Value: value}
+}
+
+func (f Field) size() int {
+	if values, ok := f.Value.([]uint16); ok {
+		return len(values) * f.Type.size()
+	}
+	return f.Type.size()
+}
+
+// FIT timestamps count seconds from 1989-12-31T00:00:00Z
+var epoch = time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func Timestamp(t time.Time) uint32 {
+	return uint32(t.Sub(epoch) / time.Second)
+}
+
+type Encoder struct {
+	data        bytes.Buffer
+	localTypes  map[uint16]uint8
+	definitions map[uint16]string
+}
+
+func NewEncoder() *Encoder {
+	return &Encoder{
+		localTypes:  make(map[uint16]uint8),
+		definitions: make(map[uint16]string),
+	}
+}
+
+// Write a data message, preceded by a definition message whenever this is the
+// first message of its kind or its fields differ from the last one written
+func (e *Encoder) WriteMessage(globalNum uint16, fields []Field) error {
+	local, ok := e.localTypes[globalNum]
+	if !ok {
+		if len(e.localTypes) >= 16 {
+			return fmt.Errorf("too many message types for one fit file")
+		}
+		local = uint8(len(e.localTypes))
+		e.localTypes[globalNum] = local
+	}
+
+	signature := fmt.Sprint(len(fields))
+	for _, f := range fields {
+		signature += fmt.Sprintf(",%d:%d:%d", f.Num, f.size(), f.Type)
+	}
+	if e.definitions[globalNum] != signature {
+		e.definitions[globalNum] = signature
+		e.data.WriteByte(0x40 | local)
+		e.data.WriteByte(0) // reserved
+		e.data.WriteByte(0) // little endian
+		binary.Write(&e.data, binary.LittleEndian, globalNum)
+		e.data.WriteByte(uint8(len(fields)))
+		for _, f := range fields {
+			e.data.Write([]byte{f.Num, uint8(f.size()), uint8(f.Type)})
+		}
+	}
+
+	e.data.WriteByte(local)
+	for _, f := range fields {
+		if err := e.writeValue(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) writeValue(f Field) error {
+	switch v := f.Value.(type) {
+	case []uint16:
+		for _, value := range v {
+			binary.Write(&e.data, binary.LittleEndian, value)
+		}
+		return nil
+	case int:
+		return e.writeUint(f.Type, uint64(v))
+	case uint8:
+		return e.writeUint(f.Type, uint64(v))
+	case uint16:
+		return e.writeUint(f.Type, uint64(v))
+	case uint32:
+		return e.writeUint(f.Type, uint64(v))
+	}
+	return fmt.Errorf("unsupported value %v for field %d", f.Value, f.Num)
+}
+
+func (e *Encoder) writeUint(t BaseType, v uint64) error {
+	switch t.size() {
+	case 1:
+		e.data.WriteByte(uint8(v))
+	case 2:
+		binary.Write(&e.data, binary.LittleEndian, uint16(v))
+	default:
+		binary.Write(&e.data, binary.LittleEndian, uint32(v))
+	}
+	return nil
+}
+
+// The complete file: header, every message written so far, and the trailing crc
+func (e *Encoder) Bytes() []byte {
+	var file bytes.Buffer
+	file.WriteByte(headerSize)
+	file.WriteByte(protocolVersion)
+	binary.Write(&file, binary.LittleEndian, uint16(profileVersion))
+	binary.Write(&file, binary.LittleEndian, uint32(e.data.Len()))
+	file.WriteString(".FIT")
+	binary.Write(&file, binary.LittleEndian, crc(file.Bytes()))
+
+	file.Write(e.data.Bytes())
+	binary.Write(&file, binary.LittleEndian, crc(file.Bytes()))
+	return file.Bytes()
+}
+
+var crcTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+func crc(data []byte) (crc uint16) {
+	for _, b := range data {
+		tmp := crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[b&0xF]
+
+		tmp = crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[(b>>4)&0xF]
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package fit
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEncodeFile(t *testing.T) {
+	e := NewEncoder()
+	err := e.WriteMessage(MesgFileId, []Field{
+		{0, Enum, uint8(4)},
+		{4, Uint32, Timestamp(time.Date(2016, 4, 28, 14, 36, 57, 0, time.UTC))},
+	})
+	assert.Nil(t, err)
+	err = e.WriteMessage(MesgSet, []Field{
+		{3, Uint16, uint16(5)},
+		{7, Uint16, []uint16{ExerciseCategories["squat"]}},
+	})
+	assert.Nil(t, err)
+
+	file := e.Bytes()
+	assert.Equal(t, byte(headerSize), file[0])
+	assert.Equal(t, ".FIT", string(file[8:12]))
+
+	//data size in the header covers everything between the header and the crc
+	dataSize := binary.LittleEndian.Uint32(file[4:8])
+	assert.Equal(t, len(file)-headerSize-2, int(dataSize))
+
+	//running the crc over a file including its own crc always yields zero
+	assert.Equal(t, uint16(0), crc(file[:headerSize]))
+	assert.Equal(t, uint16(0), crc(file))
+
+	//first message is a definition for local type 0, global message 0
+	assert.Equal(t, byte(0x40), file[headerSize])
+	assert.Equal(t, MesgFileId, binary.LittleEndian.Uint16(file[headerSize+3:]))
+}
+
+func TestTimestamp(t *testing.T) {
+	assert.Equal(t, uint32(0), Timestamp(time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, uint32(86400), Timestamp(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/csv"
+	"log"
+	"strconv"
+)
+
+// Writes the CSV layout FitNotes (Android) accepts on its "Import Workouts" screen
+type FitNotesCSVDumper struct {
+	WeightUnits string
+}
+
+func (c FitNotesCSVDumper) MappingTarget() string {
+	return "fitnotes"
+}
+
+func (c FitNotesCSVDumper) Header() []string {
+	label := "lbs"
+	if isKilograms(c.WeightUnits) {
+		label = "kgs"
+	}
+	return []string{"Date", "Exercise", "Category", "Weight (" + label + ")", "Reps", "Distance", "Distance Unit", "Time", "Comment"}
+}
+
+func (c FitNotesCSVDumper) Dump(csvWriter *csv.Writer, userActivityDetail UserActivityDetail, exerciseMapper *ExerciseMapper) {
+	e := exerciseMapper.ByFitocracyId[userActivityDetail.Activity.Id]
+
+	if e.FitNotesName == "" || e.FitNotesCategory == "" {
+		return //FitNotes won't import an exercise without a name and category
+	}
+
+	weight := 0.0
+	if isWeightUnit(userActivityDetail.Units) {
+		weight = ConvertWeight(userActivityDetail.Weight, userActivityDetail.Units, c.WeightUnits)
+	}
+	if err := csvWriter.Write([]string{
+		userActivityDetail.PerformedAt.Format("2006-01-02"),
+		e.FitNotesName,
+		e.FitNotesCategory,
+		strconv.FormatFloat(weight, 'f', 2, 64),
+		strconv.FormatFloat(userActivityDetail.Reps, 'f', -1, 64),
+		"",
+		"",
+		"",
+		"",
+	}); err != nil {
+		log.Fatalln("error writing record to csv:", err)
+	}
+}
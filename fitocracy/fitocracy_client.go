@@ -43,11 +43,14 @@ type ApiAction struct {
 	Effort2Unit      *ApiEffort        `json:"effort2_unit"`
 	Effort3Unit      *ApiEffort        `json:"effort3_unit"`
 	Activity         ApiActionActivity `json:"action"`
+	IsPR             bool              `json:"is_pr"`
+	Points           int               `json:"points"`
 }
 
 type ApiActionActivity struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
+	Id         int     `json:"id"`
+	Name       string  `json:"name"`
+	Multiplier float64 `json:"multiplier"`
 }
 
 type ApiActivityHistory struct {
@@ -69,10 +72,66 @@ func (a ApiAction) Units() string {
 	return ""
 }
 
+// Seconds per unit for the time units cardio efforts are logged in
+var secondsPerUnit = map[string]float64{
+	"sec": 1,
+	"s":   1,
+	"min": 60,
+	"hr":  3600,
+	"h":   3600,
+}
+
+// Meters per unit for the distance units cardio efforts are logged in
+var metersPerUnit = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"mi": 1609.344,
+	"yd": 0.9144,
+	"ft": 0.3048,
+}
+
+func (a ApiAction) efforts() []struct {
+	value float32
+	unit  *ApiEffort
+} {
+	return []struct {
+		value float32
+		unit  *ApiEffort
+	}{{a.Effort0, a.Effort0Unit}, {a.Effort1, a.Effort1Unit}, {a.Effort2, a.Effort2Unit}, {a.Effort3, a.Effort3Unit}}
+}
+
+// How long a cardio action lasted, in seconds. Zero for anything without a time effort.
+func (a ApiAction) Duration() float64 {
+	for _, effort := range a.efforts() {
+		if effort.unit != nil {
+			if scale, ok := secondsPerUnit[effort.unit.Abbr]; ok {
+				return float64(effort.value) * scale
+			}
+		}
+	}
+	return 0
+}
+
+// How far a cardio action went, in meters. Zero for anything without a distance effort.
+func (a ApiAction) Distance() float64 {
+	for _, effort := range a.efforts() {
+		if effort.unit != nil {
+			if scale, ok := metersPerUnit[effort.unit.Abbr]; ok {
+				return float64(effort.value) * scale
+			}
+		}
+	}
+	return 0
+}
+
 func (a ApiAction) PerformedAt() (time.Time, error) {
 	return time.Parse("2006-01-02T15:04:05", a.ActionTimeString)
 }
 
+func (h ApiActivityHistory) PerformedAt() (time.Time, error) {
+	return time.Parse("2006-01-02T15:04:05", h.TimeString)
+}
+
 func activities_url(user_id int) string {
 	return fmt.Sprintf("%sget_user_activities/%d/", fitocracy_url, user_id)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"testing"
+	"time"
 )
 
 
@@ -25,6 +26,9 @@ func TestParseActivityHistory(t *testing.T) {
 	assert.Equal(t, 898, firstActivity.Points)
 	assert.Equal(t, "2016-04-28T14:36:57", firstActivity.TimeString)
 	assert.Equal(t, "2016-04-28T15:27:42", firstActivity.OriginalTimeString)
+	performedAt, err := firstActivity.PerformedAt()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2016, 4, 28, 14, 36, 57, 0, time.UTC), performedAt)
 
 	//each action
 	assert.Equal(t, 336990561, firstActivity.Actions[0].Id)
@@ -43,4 +47,25 @@ func TestParseActivityHistory(t *testing.T) {
 	//action id's should match the action id we keep in our db
 	assert.Equal(t, 396, firstActivity.Actions[0].Activity.Id)
 	assert.Equal(t, 396, firstActivity.Actions[1].Activity.Id)
+
+	//points per action, and the activity's multiplier
+	assert.Equal(t, 63, firstActivity.Actions[0].Points)
+	assert.Equal(t, 54, firstActivity.Actions[1].Points)
+	assert.Equal(t, float64(1), firstActivity.Actions[0].Activity.Multiplier)
+}
+
+func TestCardioEfforts(t *testing.T) {
+	run := ApiAction{
+		Effort0:     1800,
+		Effort0Unit: &ApiEffort{Abbr: "sec"},
+		Effort1:     3.1,
+		Effort1Unit: &ApiEffort{Abbr: "mi"},
+	}
+	assert.Equal(t, float64(1800), run.Duration())
+	assert.InDelta(t, 4988.97, run.Distance(), 0.1)
+
+	//strength sets have neither
+	set := ApiAction{Effort0: 135, Effort0Unit: &ApiEffort{Abbr: "lb"}, Effort1: 5, Effort1Unit: &ApiEffort{Abbr: "reps"}}
+	assert.Equal(t, float64(0), set.Duration())
+	assert.Equal(t, float64(0), set.Distance())
 }
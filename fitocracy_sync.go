@@ -6,18 +6,34 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/tlianza/fitocracypal/fitocracy"
 	"encoding/csv"
-	"strconv"
+	"time"
 )
 
-type FitocracyCSVDumper struct{}
+// Dumps every set as logged in Fitocracy. Columns and Location are optional;
+// the default layout and UTC are used when they're not set.
+type FitocracyCSVDumper struct {
+	Columns  []string
+	Location *time.Location
+}
+
+func (c FitocracyCSVDumper) columns() []string {
+	if len(c.Columns) == 0 {
+		return defaultFitocracyColumns
+	}
+	return c.Columns
+}
+
+func (c FitocracyCSVDumper) Header() []string {
+	return csvHeader(c.columns())
+}
 
 func (c FitocracyCSVDumper) Dump(csvWriter *csv.Writer, userActivityDetail UserActivityDetail, exerciseMapper *ExerciseMapper) {
-	if err := csvWriter.Write([]string{userActivityDetail.PerformedAt.String(), strconv.Itoa(userActivityDetail.Activity.Id), userActivityDetail.Name, strconv.FormatFloat(userActivityDetail.Weight, 'f', -1, 32), strconv.FormatFloat(userActivityDetail.Reps, 'f', -1, 32)}); err != nil {
+	e := exerciseMapper.ByFitocracyId[userActivityDetail.Activity.Id]
+	if err := csvWriter.Write(csvRecord(c.columns(), userActivityDetail, e, c.Location)); err != nil {
 		log.Fatalln("error writing record to csv:", err)
 	}
 }
 
-
 // Does all the heavy lifting of populating the local db with everything from Fitocracy
 func PopulateDB(db *sqlx.DB, username string, password string){
 	ensureSchema(db)
@@ -119,17 +135,33 @@ func InsertActivityHistory(db *sqlx.DB, user User, ch <-chan []fitocracy.ApiActi
 		apiActivityHistoryArray := <-ch
 		for _, activityHistory := range apiActivityHistoryArray {
 			log.Printf("Looping over sets for [%d] %s\n", activityHistory.Id, activityHistory.Name)
+			workoutPerformedAt, err := activityHistory.PerformedAt()
+			if nil != err {
+				log.Fatal(err)
+			}
+			_, err = db.Exec("INSERT INTO workouts(id, user_id, name, points, performed_at) VALUES($1, $2, $3, $4, $5) ON CONFLICT(id) DO UPDATE SET name=excluded.name, points=excluded.points",
+				activityHistory.Id, user.Id, activityHistory.Name, activityHistory.Points, workoutPerformedAt)
+			if nil != err {
+				log.Fatal(err)
+			}
 			for _, apiActivityAction := range activityHistory.Actions {
 				performedAt, err := apiActivityAction.PerformedAt()
 				if nil != err {
 					log.Fatal(err)
 				}
 				log.Printf("Inserting user activity [%d] %s: %d on %s\n", apiActivityAction.Activity.Id, apiActivityAction.Activity.Name, apiActivityAction.Id, performedAt)
-				_, err = db.Exec("INSERT OR IGNORE INTO user_activities(id, user_id, fitocracy_group_id, activity_id, units, reps, weight, performed_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8)",
-					apiActivityAction.Id, user.Id, activityHistory.Id, apiActivityAction.Activity.Id, apiActivityAction.Units(), apiActivityAction.Effort1, apiActivityAction.Effort0, performedAt)
+				_, err = db.Exec("INSERT INTO user_activities(id, user_id, fitocracy_group_id, activity_id, units, reps, weight, duration, distance, is_pr, points, performed_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT(id) DO UPDATE SET duration=excluded.duration, distance=excluded.distance, is_pr=excluded.is_pr, points=excluded.points",
+					apiActivityAction.Id, user.Id, activityHistory.Id, apiActivityAction.Activity.Id, apiActivityAction.Units(), apiActivityAction.Effort1, apiActivityAction.Effort0, apiActivityAction.Duration(), apiActivityAction.Distance(), apiActivityAction.IsPR, apiActivityAction.Points, performedAt)
 				if nil != err {
 					log.Fatal(err)
 				}
+				//the activity list doesn't include multipliers, only each action does
+				if apiActivityAction.Activity.Multiplier > 0 {
+					_, err = db.Exec("UPDATE activities SET multiplier=$1 WHERE id=$2", apiActivityAction.Activity.Multiplier, apiActivityAction.Activity.Id)
+					if nil != err {
+						log.Fatal(err)
+					}
+				}
 			}
 		}
 	}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// A day (midnight in the reporting timezone) with something logged
+type TrainingDay struct {
+	Date     time.Time
+	Sets     int
+	Workouts int
+}
+
+// Every day with a set logged, in order
+func TrainingDays(details []UserActivityDetail, location *time.Location) (days []TrainingDay) {
+	byDate := map[time.Time]*TrainingDay{}
+	workouts := map[time.Time]map[int]bool{}
+	for _, detail := range details {
+		date := dayOf(detail.PerformedAt, location)
+		day, ok := byDate[date]
+		if !ok {
+			day = &TrainingDay{Date: date}
+			byDate[date] = day
+			workouts[date] = map[int]bool{}
+		}
+		day.Sets++
+		if !workouts[date][detail.FitocracyGroupId] {
+			workouts[date][detail.FitocracyGroupId] = true
+			day.Workouts++
+		}
+	}
+	for _, day := range byDate {
+		days = append(days, *day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return
+}
+
+func dayOf(t time.Time, location *time.Location) time.Time {
+	t = wallClock(t, location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+// Whole days from one date to another. Days aren't always 24 hours long
+// where clocks change, so this counts calendar days.
+func daysBetween(from time.Time, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// A run of days, or of weeks when counting weekly streaks, from Start to
+// End inclusive
+type Span struct {
+	Start  time.Time
+	End    time.Time
+	Length int
+}
+
+// Runs of consecutive training days, longest first
+func DailyStreaks(days []TrainingDay) []Span {
+	streaks := []Span{}
+	for _, day := range days {
+		if n := len(streaks); n > 0 && daysBetween(streaks[n-1].End, day.Date) == 1 {
+			streaks[n-1].End = day.Date
+			streaks[n-1].Length++
+			continue
+		}
+		streaks = append(streaks, Span{day.Date, day.Date, 1})
+	}
+	return longestFirst(streaks)
+}
+
+// Training days in each week (starting Monday) from the first training day's
+// week to the last's, including weeks with none
+type TrainingWeek struct {
+	Start time.Time
+	Days  int
+	Sets  int
+}
+
+func TrainingWeeks(days []TrainingDay) (weeks []TrainingWeek) {
+	if len(days) == 0 {
+		return
+	}
+	location := days[0].Date.Location()
+	for week := periodStart(days[0].Date, "week", location); !week.After(days[len(days)-1].Date); week = week.AddDate(0, 0, 7) {
+		weeks = append(weeks, TrainingWeek{Start: week})
+	}
+	for _, day := range days {
+		week := &weeks[daysBetween(weeks[0].Start, day.Date)/7]
+		week.Days++
+		week.Sets += day.Sets
+	}
+	return
+}
+
+// Runs of consecutive weeks with at least minDays training days, longest
+// first. Lengths are in weeks and ends are the start of the last week.
+func WeeklyStreaks(weeks []TrainingWeek, minDays int) []Span {
+	streaks := []Span{}
+	running := false
+	for _, week := range weeks {
+		if week.Days < minDays {
+			running = false
+			continue
+		}
+		if running {
+			streaks[len(streaks)-1].End = week.Start
+			streaks[len(streaks)-1].Length++
+			continue
+		}
+		streaks = append(streaks, Span{week.Start, week.Start, 1})
+		running = true
+	}
+	return longestFirst(streaks)
+}
+
+// Runs of at least minDays days without training, longest first
+func Gaps(days []TrainingDay, minDays int) []Span {
+	gaps := []Span{}
+	for i := 1; i < len(days); i++ {
+		rest := daysBetween(days[i-1].Date, days[i].Date) - 1
+		if rest >= minDays {
+			gaps = append(gaps, Span{days[i-1].Date.AddDate(0, 0, 1), days[i].Date.AddDate(0, 0, -1), rest})
+		}
+	}
+	return longestFirst(gaps)
+}
+
+func longestFirst(spans []Span) []Span {
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Length > spans[j].Length })
+	return spans
+}
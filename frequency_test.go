@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreaksAndGaps(t *testing.T) {
+	details := []UserActivityDetail{}
+	for i, day := range []int{4, 5, 6, 6, 8, 20, 21} {
+		set := testSet(i+1, day, 2, 5, 100, "kg")
+		set.PerformedAt = time.Date(2016, 1, day, 18, 0, 0, 0, time.UTC)
+		details = append(details, set)
+	}
+	details[3].FitocracyGroupId = 60 //a second workout that day
+	days := TrainingDays(details, time.UTC)
+	assert.Len(t, days, 6)
+	assert.Equal(t, 2, days[2].Sets)
+	assert.Equal(t, 2, days[2].Workouts)
+
+	streaks := DailyStreaks(days)
+	assert.Equal(t, Span{days[0].Date, days[2].Date, 3}, streaks[0])
+
+	weeks := TrainingWeeks(days)
+	assert.Equal(t, []int{4, 0, 2}, []int{weeks[0].Days, weeks[1].Days, weeks[2].Days})
+	assert.Equal(t, 1, WeeklyStreaks(weeks, 1)[0].Length)
+
+	gaps := Gaps(days, 7)
+	assert.Len(t, gaps, 1)
+	assert.Equal(t, 11, gaps[0].Length)
+	assert.Equal(t, 9, gaps[0].Start.Day())
+}
+
+func TestWriteHeatmap(t *testing.T) {
+	days := []TrainingDay{
+		{Date: time.Date(2015, 12, 31, 0, 0, 0, 0, time.UTC), Sets: 20, Workouts: 1},
+		{Date: time.Date(2016, 1, 4, 0, 0, 0, 0, time.UTC), Sets: 5, Workouts: 1},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, WriteHeatmap(&buf, days))
+	svg := buf.String()
+	assert.Equal(t, 365+366, strings.Count(svg, "<rect"))
+	assert.Contains(t, svg, `fill="#216e39"><title>Thu Dec 31, 2015: 20 sets in 1 workout(s)</title>`)
+	assert.Contains(t, svg, `fill="#9be9a8"><title>Mon Jan 4, 2016: 5 sets in 1 workout(s)</title>`)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/tlianza/fitocracypal/fit"
+)
+
+// FIT values for the enums we use. See the FIT SDK profile for the rest.
+const (
+	fitFileActivity     = 4
+	fitManufacturerDev  = 255
+	fitSportTraining    = 10
+	fitSubSportStrength = 20
+	fitEventTimer       = 0
+	fitEventSession     = 8
+	fitEventLap         = 9
+	fitEventActivity    = 26
+	fitEventTypeStart   = 0
+	fitEventTypeStop    = 1
+	fitEventTypeStopAll = 4
+	fitSetTypeActive    = 1
+	fitDisplayKilograms = 1
+	fitDisplayPounds    = 2
+	fitInvalidUint16    = 0xFFFF
+	fitSecondsScale     = 1000
+	fitWeightScale      = 16
+)
+
+// Writes one FIT activity file per Fitocracy workout, with a strength-training
+// set message for every set. Garmin Connect only imports one activity per
+// file, so the files are bundled into a zip to be extracted before uploading.
+// Exercises without a garmin_category are categorized by their metadata.
+type GarminFITExporter struct{}
+
+func (g GarminFITExporter) MappingTarget() string {
+	return "garmin"
+}
+
+func (g GarminFITExporter) FileExtension() string {
+	return "zip"
+}
+
+func (g GarminFITExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	archive := zip.NewWriter(w)
+	unknown := map[string]bool{}
+
+	for _, group := range GroupWorkouts(details) {
+		if len(fitStrengthSets(group.Details)) == 0 {
+			continue
+		}
+		encoder := fit.NewEncoder()
+		if err := writeFITWorkout(encoder, group, exerciseMapper, unknown); err != nil {
+			return err
+		}
+
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("%s-%d.fit", group.Start().Format("2006-01-02"), group.Id),
+			Method:   zip.Deflate,
+			Modified: group.Start(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(encoder.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	for name := range unknown {
+		log.Printf("Unknown garmin_category %q in exercise mappings\n", name)
+	}
+	return archive.Close()
+}
+
+// The sets a FIT strength activity can hold: no cardio, and no sets with
+// something other than a weight in the weight column. Bodyweight sets have
+// no weight and are kept.
+func fitStrengthSets(details []UserActivityDetail) (sets []UserActivityDetail) {
+	for _, detail := range details {
+		if !detail.IsStrength() {
+			continue
+		}
+		sets = append(sets, detail)
+	}
+	return
+}
+
+func writeFITWorkout(encoder *fit.Encoder, group *WorkoutGroup, exerciseMapper *ExerciseMapper, unknown map[string]bool) error {
+	start := group.Start()
+	end := start.Add(group.Duration())
+	elapsed := uint32(end.Sub(start).Seconds() * fitSecondsScale)
+
+	messages := []struct {
+		num    uint16
+		fields []fit.Field
+	}{
+		{fit.MesgFileId, []fit.Field{
+			fit.NewField(0, fit.Enum, fitFileActivity),
+			fit.NewField(1, fit.Uint16, fitManufacturerDev),
+			fit.NewField(2, fit.Uint16, 0),
+			fit.NewField(3, fit.Uint32z, uint32(group.Id)),
+			fit.NewField(4, fit.Uint32, fit.Timestamp(start)),
+		}},
+		{fit.MesgEvent, []fit.Field{
+			fit.NewField(253, fit.Uint32, fit.Timestamp(start)),
+			fit.NewField(0, fit.Enum, fitEventTimer),
+			fit.NewField(1, fit.Enum, fitEventTypeStart),
+		}},
+	}
+	for _, m := range messages {
+		if err := encoder.WriteMessage(m.num, m.fields); err != nil {
+			return err
+		}
+	}
+
+	for i, detail := range fitStrengthSets(group.Details) {
+		e := exerciseMapper.ByFitocracyId[detail.Activity.Id]
+		category, ok := fit.ExerciseCategories[e.garminCategory()]
+		if !ok {
+			if e.garminCategory() != "" {
+				unknown[e.garminCategory()] = true
+			}
+			category = fit.ExerciseCategories["unknown"]
+		}
+		subcategory := uint16(fitInvalidUint16)
+		if ok && e.GarminCategory != "" && e.GarminSubcategory != nil {
+			subcategory = uint16(*e.GarminSubcategory)
+		}
+		displayUnit := fitDisplayPounds
+		if isKilograms(detail.Units) {
+			displayUnit = fitDisplayKilograms
+		}
+		weight := ConvertWeight(detail.Weight, detail.Units, "kg")
+
+		err := encoder.WriteMessage(fit.MesgSet, []fit.Field{
+			fit.NewField(254, fit.Uint32, fit.Timestamp(detail.PerformedAt)),
+			fit.NewField(6, fit.Uint32, fit.Timestamp(detail.PerformedAt)),
+			fit.NewField(3, fit.Uint16, uint16(detail.Reps)),
+			fit.NewField(4, fit.Uint16, uint16(weight*fitWeightScale+0.5)),
+			fit.NewField(5, fit.Uint8, fitSetTypeActive),
+			fit.NewField(7, fit.Uint16, []uint16{category}),
+			fit.NewField(8, fit.Uint16, []uint16{subcategory}),
+			fit.NewField(9, fit.Uint16, displayUnit),
+			fit.NewField(10, fit.Uint16, i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	messages = []struct {
+		num    uint16
+		fields []fit.Field
+	}{
+		{fit.MesgEvent, []fit.Field{
+			fit.NewField(253, fit.Uint32, fit.Timestamp(end)),
+			fit.NewField(0, fit.Enum, fitEventTimer),
+			fit.NewField(1, fit.Enum, fitEventTypeStopAll),
+		}},
+		{fit.MesgLap, []fit.Field{
+			fit.NewField(253, fit.Uint32, fit.Timestamp(end)),
+			fit.NewField(2, fit.Uint32, fit.Timestamp(start)),
+			fit.NewField(7, fit.Uint32, elapsed),
+			fit.NewField(8, fit.Uint32, elapsed),
+			fit.NewField(0, fit.Enum, fitEventLap),
+			fit.NewField(1, fit.Enum, fitEventTypeStop),
+			fit.NewField(25, fit.Enum, fitSportTraining),
+			fit.NewField(39, fit.Enum, fitSubSportStrength),
+		}},
+		{fit.MesgSession, []fit.Field{
+			fit.NewField(253, fit.Uint32, fit.Timestamp(end)),
+			fit.NewField(2, fit.Uint32, fit.Timestamp(start)),
+			fit.NewField(7, fit.Uint32, elapsed),
+			fit.NewField(8, fit.Uint32, elapsed),
+			fit.NewField(0, fit.Enum, fitEventSession),
+			fit.NewField(1, fit.Enum, fitEventTypeStop),
+			fit.NewField(5, fit.Enum, fitSportTraining),
+			fit.NewField(6, fit.Enum, fitSubSportStrength),
+			fit.NewField(25, fit.Uint16, 0),
+			fit.NewField(26, fit.Uint16, 1),
+		}},
+		{fit.MesgActivity, []fit.Field{
+			fit.NewField(253, fit.Uint32, fit.Timestamp(end)),
+			fit.NewField(0, fit.Uint32, elapsed),
+			fit.NewField(1, fit.Uint16, 1),
+			fit.NewField(2, fit.Enum, 0),
+			fit.NewField(3, fit.Enum, fitEventActivity),
+			fit.NewField(4, fit.Enum, fitEventTypeStop),
+		}},
+	}
+	for _, m := range messages {
+		if err := encoder.WriteMessage(m.num, m.fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
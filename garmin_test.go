@@ -0,0 +1,33 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFITStrengthSets(t *testing.T) {
+	run := testSet(3, 1, 518, 0, 0, "")
+	run.Duration = 1800
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 396, 20, 0, "reps"), //bodyweight, kept without a weight
+		run,
+		testSet(4, 1, 518, 0, 30, "min"), //cardio synced without a duration
+		testSet(5, 2, 518, 0, 3.1, "mi"),
+	}
+	sets := fitStrengthSets(details)
+	assert.Len(t, sets, 2)
+	assert.Equal(t, 1, sets[0].UserActivity.Id)
+	assert.Equal(t, 2, sets[1].UserActivity.Id)
+
+	//the cardio-only workout gets no file
+	var out bytes.Buffer
+	assert.NoError(t, GarminFITExporter{}.Export(&out, details, NewExerciseMapper(nil)))
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, archive.File, 1)
+	assert.Equal(t, "2016-01-01-1.fit", archive.File[0].Name)
+}
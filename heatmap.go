@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+// Cell colours from no training to the most, as on GitHub's contribution graph
+var heatmapColors = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+const (
+	heatmapCell   = 11
+	heatmapStep   = 13 //cell plus gap
+	heatmapLeft   = 30 //room for weekday labels
+	heatmapTop    = 30 //room for the year and month labels
+	heatmapYearHt = heatmapTop + 7*heatmapStep + 20
+)
+
+type heatmapCellData struct {
+	X, Y  int
+	Color string
+	Title string
+}
+
+type heatmapLabel struct {
+	X, Y int
+	Text string
+}
+
+type heatmapYear struct {
+	Y      int
+	Year   int
+	Cells  []heatmapCellData
+	Months []heatmapLabel
+}
+
+type heatmapData struct {
+	Width, Height int
+	Cell          int
+	Years         []heatmapYear
+	Weekdays      []heatmapLabel
+}
+
+const heatmapTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" font-family="sans-serif" font-size="9" fill="#767676">
+{{- range $year := .Years}}
+<g transform="translate(0,{{.Y}})">
+<text x="0" y="11" font-size="11" fill="#24292e">{{.Year}}</text>
+{{- range .Months}}
+<text x="{{.X}}" y="{{.Y}}">{{.Text}}</text>
+{{- end}}
+{{- range $.Weekdays}}
+<text x="0" y="{{.Y}}">{{.Text}}</text>
+{{- end}}
+{{- range .Cells}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{$.Cell}}" height="{{$.Cell}}" rx="2" fill="{{.Color}}"><title>{{.Title}}</title></rect>
+{{- end}}
+</g>
+{{- end}}
+</svg>
+`
+
+var heatmapSVG = template.Must(template.New("heatmap").Parse(heatmapTemplate))
+
+// Writes a calendar heatmap of sets per day as an SVG: a row per year, a
+// column per week and a cell per day, darker for more sets. Colours are
+// scaled to the busiest day.
+func WriteHeatmap(w io.Writer, days []TrainingDay) error {
+	data := heatmapData{Width: heatmapLeft + 54*heatmapStep, Cell: heatmapCell}
+	for i, name := range []string{"Mon", "", "Wed", "", "Fri", "", ""} {
+		if name != "" {
+			data.Weekdays = append(data.Weekdays, heatmapLabel{0, heatmapTop + i*heatmapStep + 9, name})
+		}
+	}
+	if len(days) == 0 {
+		data.Height = heatmapYearHt
+		return heatmapSVG.Execute(w, data)
+	}
+
+	busiest := 0
+	sets := map[time.Time]TrainingDay{}
+	for _, day := range days {
+		sets[day.Date] = day
+		if day.Sets > busiest {
+			busiest = day.Sets
+		}
+	}
+
+	location := days[0].Date.Location()
+	for year := days[0].Date.Year(); year <= days[len(days)-1].Date.Year(); year++ {
+		y := heatmapYear{Y: len(data.Years) * heatmapYearHt, Year: year}
+		first := time.Date(year, 1, 1, 0, 0, 0, 0, location)
+		firstWeek := periodStart(first, "week", location)
+		for date := first; date.Year() == year; date = date.AddDate(0, 0, 1) {
+			column := daysBetween(firstWeek, date) / 7
+			row := (int(date.Weekday()) + 6) % 7
+			x := heatmapLeft + column*heatmapStep
+			if date.Day() == 1 {
+				y.Months = append(y.Months, heatmapLabel{x, heatmapTop - 4, date.Format("Jan")})
+			}
+			day := sets[date]
+			level := 0
+			if day.Sets > 0 {
+				level = (day.Sets*(len(heatmapColors)-1) + busiest - 1) / busiest
+			}
+			title := date.Format("Mon Jan 2, 2006") + ": no training"
+			if day.Sets > 0 {
+				title = fmt.Sprintf("%s: %d sets in %d workout(s)", date.Format("Mon Jan 2, 2006"), day.Sets, day.Workouts)
+			}
+			y.Cells = append(y.Cells, heatmapCellData{x, heatmapTop + row*heatmapStep, heatmapColors[level], title})
+		}
+		data.Years = append(data.Years, y)
+	}
+	data.Height = len(data.Years) * heatmapYearHt
+	return heatmapSVG.Execute(w, data)
+}
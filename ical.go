@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Writes an iCalendar file with a VEVENT per workout, spanning the time the
+// workout was logged over and listing each exercise's sets in the description.
+// Fitocracy logs wall-clock times, which are taken to be in Location (UTC
+// when it's not set) and written as UTC, so no VTIMEZONE is needed.
+type ICalExporter struct {
+	Location *time.Location
+}
+
+const icalTimeFormat = "20060102T150405Z"
+
+// A DTSTART or DTEND property for a stored wall-clock time
+func (i ICalExporter) timeProperty(name string, t time.Time) string {
+	location := i.Location
+	if location == nil {
+		location = time.UTC
+	}
+	return name + ":" + wallClock(t, location).UTC().Format(icalTimeFormat)
+}
+
+func (i ICalExporter) FileExtension() string {
+	return "ics"
+}
+
+func (i ICalExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//fitocracypal//Fitocracy workouts//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Fitocracy workouts",
+	}
+	now := time.Now().UTC().Format(icalTimeFormat)
+	for _, workout := range journalWorkouts(details) {
+		summary := []string{}
+		for _, exercise := range workout.Exercises {
+			summary = append(summary, exercise.Name+": "+strings.Join(exercise.Sets, ", "))
+		}
+		title := workout.Name
+		if workout.Points > 0 {
+			title = fmt.Sprintf("%s (%d pts)", title, workout.Points)
+		}
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%d@fitocracypal", workout.Id),
+			"DTSTAMP:"+now,
+			i.timeProperty("DTSTART", workout.Start),
+			i.timeProperty("DTEND", workout.Start.Add(workout.Duration)),
+			"SUMMARY:"+icalEscape(title),
+			"DESCRIPTION:"+icalEscape(strings.Join(summary, "\n")),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, icalFold(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// Lines longer than 75 octets are continued on the next line after a space,
+// taking care not to split a multi-byte character
+func icalFold(line string) string {
+	var b strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > 75 {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICalEventTimes(t *testing.T) {
+	details := []UserActivityDetail{testSet(1, 1, 2, 5, 100, "kg"), testSet(2, 1, 2, 5, 100, "kg")}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no timezone database: ", err)
+	}
+
+	//logged at 12:00:01 wall-clock time in New York, which is 17:00:01 UTC
+	var ics bytes.Buffer
+	assert.NoError(t, ICalExporter{Location: newYork}.Export(&ics, details, NewExerciseMapper(nil)))
+	assert.Contains(t, ics.String(), "DTSTART:20160101T170001Z\r\n")
+	assert.Contains(t, ics.String(), "DTEND:20160101T170002Z\r\n")
+	assert.NotContains(t, ics.String(), "TZID")
+
+	ics.Reset()
+	assert.NoError(t, ICalExporter{}.Export(&ics, details, NewExerciseMapper(nil)))
+	assert.Contains(t, ics.String(), "DTSTART:20160101T120001Z\r\n")
+}
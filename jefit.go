@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/csv"
+	"log"
+	"strconv"
+)
+
+// Writes one row per set in the layout JEFIT's CSV log import expects. JEFIT
+// wants sets numbered within each exercise of a workout, so this dumper keeps
+// track of the last workout/exercise it saw and relies on rows arriving in
+// the order they were performed.
+type JefitCSVDumper struct {
+	WeightUnits string
+	groupId     int
+	activityId  int
+	set         int
+}
+
+func (c *JefitCSVDumper) MappingTarget() string {
+	return "jefit"
+}
+
+func (c *JefitCSVDumper) Header() []string {
+	return []string{"Date", "Exercise", "Set", "Weight", "Reps", "Units"}
+}
+
+func (c *JefitCSVDumper) Dump(csvWriter *csv.Writer, userActivityDetail UserActivityDetail, exerciseMapper *ExerciseMapper) {
+	e := exerciseMapper.ByFitocracyId[userActivityDetail.Activity.Id]
+
+	if e.JefitName == "" {
+		return //can't add to csv
+	}
+
+	if c.groupId != userActivityDetail.FitocracyGroupId || c.activityId != userActivityDetail.Activity.Id {
+		c.groupId = userActivityDetail.FitocracyGroupId
+		c.activityId = userActivityDetail.Activity.Id
+		c.set = 0
+	}
+	c.set++
+
+	weight := 0.0
+	if isWeightUnit(userActivityDetail.Units) {
+		weight = ConvertWeight(userActivityDetail.Weight, userActivityDetail.Units, c.WeightUnits)
+	}
+	if err := csvWriter.Write([]string{
+		userActivityDetail.PerformedAt.Format("2006-01-02"),
+		e.JefitName,
+		strconv.Itoa(c.set),
+		strconv.FormatFloat(weight, 'f', 2, 64),
+		strconv.FormatFloat(userActivityDetail.Reps, 'f', -1, 64),
+		c.WeightUnits,
+	}); err != nil {
+		log.Fatalln("error writing record to csv:", err)
+	}
+}
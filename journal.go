@@ -0,0 +1,111 @@
+package main
+
+import (
+	htmltemplate "html/template"
+	"io"
+	texttemplate "text/template"
+	"time"
+)
+
+// A human-readable training log: a section per workout with its name, points
+// and every exercise's sets. The same data feeds a Markdown and an HTML template.
+type journalWorkout struct {
+	Id        int
+	Start     time.Time
+	Duration  time.Duration
+	Date      string
+	Name      string
+	Points    int
+	Exercises []*journalExercise
+}
+
+type journalExercise struct {
+	Name string
+	Sets []string
+}
+
+func journalWorkouts(details []UserActivityDetail) (workouts []journalWorkout) {
+	for _, group := range GroupWorkouts(details) {
+		workout := journalWorkout{
+			Id:       group.Id,
+			Start:    group.Start(),
+			Duration: group.Duration(),
+			Date:     group.Start().Format("Monday, January 2, 2006"),
+			Name:     group.Details[0].WorkoutName,
+			Points:   group.Details[0].WorkoutPoints,
+		}
+		if workout.Name == "" {
+			workout.Name = "Workout"
+		}
+
+		byActivity := map[int]*journalExercise{}
+		for _, detail := range group.Details {
+			exercise, ok := byActivity[detail.Activity.Id]
+			if !ok {
+				exercise = &journalExercise{Name: detail.Activity.Name}
+				byActivity[detail.Activity.Id] = exercise
+				workout.Exercises = append(workout.Exercises, exercise)
+			}
+			exercise.Sets = append(exercise.Sets, detail.Describe())
+		}
+		workouts = append(workouts, workout)
+	}
+	return
+}
+
+const markdownJournalTemplate = `# Training Journal
+{{range .}}
+## {{.Date}}: {{.Name}}{{if .Points}} ({{.Points}} pts){{end}}
+{{range .Exercises}}
+**{{.Name}}**
+{{range .Sets}}
+- {{.}}{{end}}
+{{end}}{{end}}`
+
+const htmlJournalTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Training Journal</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; }
+section { page-break-inside: avoid; border-top: 1px solid #ccc; }
+h3 { margin-bottom: 0.2em; }
+ul { margin-top: 0; }
+</style>
+</head>
+<body>
+<h1>Training Journal</h1>
+{{range .}}<section>
+<h2>{{.Date}}: {{.Name}}{{if .Points}} <small>({{.Points}} pts)</small>{{end}}</h2>
+{{range .Exercises}}<h3>{{.Name}}</h3>
+<ul>
+{{range .Sets}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}</section>
+{{end}}</body>
+</html>
+`
+
+var markdownJournal = texttemplate.Must(texttemplate.New("journal").Parse(markdownJournalTemplate))
+var htmlJournal = htmltemplate.Must(htmltemplate.New("journal").Parse(htmlJournalTemplate))
+
+type MarkdownJournalExporter struct{}
+
+func (m MarkdownJournalExporter) FileExtension() string {
+	return "md"
+}
+
+func (m MarkdownJournalExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	return markdownJournal.Execute(w, journalWorkouts(details))
+}
+
+type HTMLJournalExporter struct{}
+
+func (h HTMLJournalExporter) FileExtension() string {
+	return "html"
+}
+
+func (h HTMLJournalExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	return htmlJournal.Execute(w, journalWorkouts(details))
+}
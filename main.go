@@ -3,41 +3,43 @@ package main
 import (
 	"encoding/csv"
 	"flag"
+	"io"
 	"log"
-	"os"
 
-	"github.com/BurntSushi/toml"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/viper"
 )
 
-type Config struct {
-	Exercises []Exercise `toml:"exercises"`
-}
-
 func main() {
 	//load up our application config
 	viper.SetConfigName("config")
 	viper.AddConfigPath(".")
+	viper.SetDefault("weight_units", "lbs")
+	viper.SetDefault("timezone", "UTC")
+	viper.SetDefault("one_rep_max_formula", "epley")
+	viper.SetDefault("plateau_weeks", 8)
+	viper.SetDefault("regression_drop", 10)
+	viper.SetDefault("sex", "male")
+	viper.SetDefault("powerlifting.squat", 2)
+	viper.SetDefault("powerlifting.bench", 1)
+	viper.SetDefault("powerlifting.deadlift", 3)
 	err := viper.ReadInConfig() // Find and read the config file
 	if err != nil {             // Handle errors reading the config file
 		log.Fatalf("Fatal error config file: %s \n", err)
 	}
 
 	//load up our mapping file
-	var config Config
-	_, err = toml.DecodeFile(viper.GetString("exercise_mappings"), &config)
+	exercises, err := LoadMappingFile(viper.GetString("exercise_mappings"))
 	if err != nil {
 		log.Fatalf("Fatal error reading mapping file %s: %s \n", viper.GetString("exercise_mappings"), err)
 	}
 
-	exerciseMapper := NewExerciseMapper(config.Exercises)
-
 	db, err := getDB()
 	if err != nil {
 		log.Fatal("error connecting to the database: ", err)
 	}
+	ensureSchema(db)
 	username := flag.String("user", "", "Fitocracy Username")
 	password := flag.String("pass", "", "Fitocracy Password")
 	flag.Parse()
@@ -56,13 +58,62 @@ func main() {
 		PopulateDB(db, *username, *password)
 	}
 
-	err = DumpCSV(db, *username, viper.GetString("fitocracy_csv"), exerciseMapper, FitocracyCSVDumper{})
+	//Sync the mapping file into the db, then map with it plus the user's own overrides
+	err = SyncExerciseMappings(db, exercises)
 	if err != nil {
-		log.Fatal("error generating csv: ", err)
+		log.Fatal("error syncing exercise mappings: ", err)
 	}
-	err = DumpCSV(db, *username, viper.GetString("virtuagym_csv"), exerciseMapper, VirtuaGymCSVDumper{})
+	_, user := GetUserByUsername(db, *username) //no user yet just means no overrides
+	err, mappings := GetExerciseMappings(db, user.Id)
 	if err != nil {
-		log.Fatal("error generating csv: ", err)
+		log.Fatal("error loading exercise mappings: ", err)
+	}
+	exerciseMapper := NewExerciseMapper(mappings)
+
+	//Anything after the flags is a command; with no command we dump the default csvs
+	switch flag.Arg(0) {
+	case "":
+		fitocracyDumper, virtuaGymDumper := configuredCSVDumpers()
+		err = DumpCSV(db, *username, viper.GetString("fitocracy_csv"), exerciseMapper, fitocracyDumper)
+		if err != nil {
+			log.Fatal("error generating csv: ", err)
+		}
+		err = DumpCSV(db, *username, viper.GetString("virtuagym_csv"), exerciseMapper, virtuaGymDumper)
+		if err != nil {
+			log.Fatal("error generating csv: ", err)
+		}
+	case "mappings":
+		err = runMappings(db, *username, exerciseMapper, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "export":
+		err = runExport(db, *username, exerciseMapper, flag.Args()[1:])
+		if err != nil {
+			log.Fatal("error exporting: ", err)
+		}
+	case "chart":
+		err = runChart(db, *username, exerciseMapper, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "plan":
+		err = runPlan(db, *username, exerciseMapper, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "bodyweight":
+		err = runBodyWeight(db, *username, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "stats":
+		err = runStats(db, *username, exerciseMapper, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("Unknown command %s", flag.Arg(0))
 	}
 }
 
@@ -70,39 +121,46 @@ type CSVDumper interface {
 	Dump(*csv.Writer, UserActivityDetail, *ExerciseMapper)
 }
 
+// Dumpers for apps that need a header row to import a file implement this too
+type CSVHeader interface {
+	Header() []string
+}
+
 // Dump the contents of an already populated db into a csv
 func DumpCSV(db *sqlx.DB, username string, filename string, exerciseMapper *ExerciseMapper, dumper CSVDumper) (err error) {
+	return ExportFile(db, username, filename, exerciseMapper, csvExporter{dumper}, ActivityFilter{}, false)
+}
 
-	err, user := GetUserByUsername(db, username)
-	if nil != err {
-		log.Fatal(err)
-	}
+// Adapts a row-at-a-time CSVDumper to the Exporter interface
+type csvExporter struct {
+	dumper CSVDumper
+}
 
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0777)
-	defer file.Close()
-	if err != nil {
-		os.Exit(1)
+func (c csvExporter) MappingTarget() string {
+	if mapped, ok := c.dumper.(MappedExporter); ok {
+		return mapped.MappingTarget()
 	}
+	return ""
+}
 
-	rows, err := db.Queryx("SELECT * FROM user_activities JOIN activities ON user_activities.activity_id=activities.id WHERE user_id=$1", user.Id)
-	if nil != err {
-		log.Fatal(err)
-	}
+func (c csvExporter) FileExtension() string {
+	return "csv"
+}
 
-	csvWriter := csv.NewWriter(file)
+func (c csvExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	csvWriter := csv.NewWriter(w)
 
-	for rows.Next() {
-		userActivityDetail := UserActivityDetail{}
-		err := rows.StructScan(&userActivityDetail)
-		if err != nil {
+	if header, ok := c.dumper.(CSVHeader); ok {
+		if err := csvWriter.Write(header.Header()); err != nil {
 			return err
 		}
-		dumper.Dump(csvWriter, userActivityDetail, exerciseMapper)
+	}
+
+	for _, userActivityDetail := range details {
+		c.dumper.Dump(csvWriter, userActivityDetail, exerciseMapper)
 	}
 
 	// Write any buffered data to the underlying writer
 	csvWriter.Flush()
-	log.Printf("Wrote csv output to %s\n", filename)
-
-	return
+	return csvWriter.Error()
 }
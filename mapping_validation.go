@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// What identifies an exercise in each target app, both in our mappings and
+// in that app's catalog. Two Fitocracy exercises with the same key map to
+// the same target exercise.
+var mappingKeys = map[string]struct {
+	exercise func(Exercise) string
+	catalog  func(CatalogEntry) string
+}{
+	"virtuagym": {
+		func(e Exercise) string { return idKey(e.VirtuaGymId) },
+		func(c CatalogEntry) string { return idKey(c.Id) },
+	},
+	"mfp": {
+		func(e Exercise) string { return idKey(e.MFPId) },
+		func(c CatalogEntry) string { return idKey(c.Id) },
+	},
+	"fitnotes": {
+		func(e Exercise) string { return nameKey(e.FitNotesName) },
+		func(c CatalogEntry) string { return nameKey(c.Name) },
+	},
+	"jefit": {
+		func(e Exercise) string { return nameKey(e.JefitName) },
+		func(c CatalogEntry) string { return nameKey(c.Name) },
+	},
+	"strong": {
+		func(e Exercise) string { return nameKey(e.StrongName) },
+		func(c CatalogEntry) string { return nameKey(c.Name) },
+	},
+}
+
+func idKey(id int) string {
+	if id <= 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func mappingKeyTargets() []string {
+	targets := []string{}
+	for target := range mappingKeys {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// Everything wrong with a list of mappings on its own: missing or duplicate
+// Fitocracy ids, names missing where an id or name is set and metadata
+// values we don't know
+func ValidateMappings(exercises []Exercise) (problems []string) {
+	byFitocracyId := map[int]int{}
+	for _, e := range exercises {
+		byFitocracyId[e.FitocracyId]++
+	}
+
+	for i, e := range exercises {
+		label := fmt.Sprintf("[%d] %s", e.FitocracyId, e.FitocracyName)
+		if e.FitocracyId <= 0 {
+			problems = append(problems, fmt.Sprintf("entry %d (%s) has no fitocracy_id", i+1, e.FitocracyName))
+		}
+		if strings.TrimSpace(e.FitocracyName) == "" {
+			problems = append(problems, fmt.Sprintf("%s has no fitocracy_name", label))
+		}
+		if count := byFitocracyId[e.FitocracyId]; count > 1 && e.FitocracyId > 0 {
+			problems = append(problems, fmt.Sprintf("%s: fitocracy_id appears %d times", label, count))
+			byFitocracyId[e.FitocracyId] = 0 //only report each duplicate once
+		}
+		if e.VirtuaGymId > 0 && e.VirtuaGymName == "" {
+			problems = append(problems, fmt.Sprintf("%s has a virtuagym_id but no virtuagym_name", label))
+		}
+		if e.MFPId > 0 && e.MFPName == "" {
+			problems = append(problems, fmt.Sprintf("%s has an mfp_id but no mfp_name", label))
+		}
+		if (e.FitNotesName == "") != (e.FitNotesCategory == "") {
+			problems = append(problems, fmt.Sprintf("%s needs both fitnotes_name and fitnotes_category", label))
+		}
+		for _, muscle := range append(append([]string{}, e.PrimaryMuscles...), e.SecondaryMuscles...) {
+			if !isOneOf(muscle, muscleGroups) {
+				problems = append(problems, fmt.Sprintf("%s has unknown muscle group %q", label, muscle))
+			}
+		}
+		if e.Equipment != "" && !isOneOf(e.Equipment, equipmentTypes) {
+			problems = append(problems, fmt.Sprintf("%s has unknown equipment %q", label, e.Equipment))
+		}
+		if e.MovementPattern != "" && !isOneOf(e.MovementPattern, movementPatterns) {
+			problems = append(problems, fmt.Sprintf("%s has unknown movement_pattern %q", label, e.MovementPattern))
+		}
+	}
+	return
+}
+
+// Target exercises that several Fitocracy exercises are mapped to. Variants
+// of a lift often share one target exercise, so these aren't always mistakes.
+func MappingCollisions(exercises []Exercise) (collisions []string) {
+	for _, target := range mappingKeyTargets() {
+		byKey := map[string][]string{}
+		keys := []string{}
+		for _, e := range exercises {
+			key := mappingKeys[target].exercise(e)
+			if key == "" {
+				continue
+			}
+			if _, ok := byKey[key]; !ok {
+				keys = append(keys, key)
+			}
+			byKey[key] = append(byKey[key], fmt.Sprintf("[%d] %s", e.FitocracyId, e.FitocracyName))
+		}
+		for _, key := range keys {
+			if len(byKey[key]) > 1 {
+				collisions = append(collisions, fmt.Sprintf("%s %q is mapped from %d exercises: %s", target, key, len(byKey[key]), strings.Join(byKey[key], ", ")))
+			}
+		}
+	}
+	return
+}
+
+// Mappings for the target that don't exist in its catalog
+func ValidateMappingsAgainstCatalog(exercises []Exercise, target string, catalog []CatalogEntry) (problems []string) {
+	inCatalog := map[string]bool{}
+	for _, entry := range catalog {
+		inCatalog[mappingKeys[target].catalog(entry)] = true
+	}
+	for _, e := range exercises {
+		key := mappingKeys[target].exercise(e)
+		if key != "" && !inCatalog[key] {
+			problems = append(problems, fmt.Sprintf("[%d] %s: %s %q is not in the catalog", e.FitocracyId, e.FitocracyName, target, key))
+		}
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateMappings(t *testing.T) {
+	exercises := []Exercise{
+		{FitocracyId: 1, FitocracyName: "Barbell Squat", VirtuaGymId: 354, VirtuaGymName: "Squat - Barbell"},
+		{FitocracyId: 1, FitocracyName: "Back Squat"},
+		{FitocracyId: 2, FitocracyName: "Barbell Bench Press", MFPId: 7},
+		{FitocracyId: 3, FitocracyName: "Pendlay Row", FitNotesName: "Barbell Row"},
+		{FitocracyId: 4, FitocracyName: "Push-Up", PrimaryMuscles: MuscleList{"pecs"}, MovementPattern: "push"},
+	}
+	assert.Equal(t, []string{
+		"[1] Barbell Squat: fitocracy_id appears 2 times",
+		"[2] Barbell Bench Press has an mfp_id but no mfp_name",
+		"[3] Pendlay Row needs both fitnotes_name and fitnotes_category",
+		`[4] Push-Up has unknown muscle group "pecs"`,
+		`[4] Push-Up has unknown movement_pattern "push"`,
+	}, ValidateMappings(exercises))
+}
+
+func TestMappingCollisions(t *testing.T) {
+	exercises := []Exercise{
+		{FitocracyId: 153, FitocracyName: "Bent Over Barbell Row", VirtuaGymId: 339, JefitName: "Barbell Row"},
+		{FitocracyId: 532, FitocracyName: "Pendlay Row", VirtuaGymId: 339, JefitName: "Pendlay Row"},
+	}
+	assert.Equal(t, []string{
+		`virtuagym "339" is mapped from 2 exercises: [153] Bent Over Barbell Row, [532] Pendlay Row`,
+	}, MappingCollisions(exercises))
+}
+
+func TestValidateMappingsAgainstCatalog(t *testing.T) {
+	exercises := []Exercise{
+		{FitocracyId: 1, FitocracyName: "Barbell Squat", StrongName: "Squat (Barbell)"},
+		{FitocracyId: 2, FitocracyName: "Barbell Bench Press", StrongName: "Bench Press"},
+	}
+	catalog := []CatalogEntry{{Name: "squat (barbell)"}, {Name: "Bench Press (Barbell)"}}
+	assert.Equal(t, []string{
+		`[2] Barbell Bench Press: strong "bench press" is not in the catalog`,
+	}, ValidateMappingsAgainstCatalog(exercises, "strong", catalog))
+}
+
+func TestCategoriesFromMetadata(t *testing.T) {
+	pushUp := Exercise{MovementPattern: "horizontal_push", Bodyweight: true}
+	assert.Equal(t, "push_up", pushUp.garminCategory())
+	assert.Equal(t, "FunctionalStrengthTraining", pushUp.appleActivityType())
+
+	curl := Exercise{MovementPattern: "isolation", PrimaryMuscles: MuscleList{"biceps"}, Equipment: "cable"}
+	assert.Equal(t, "curl", curl.garminCategory())
+	assert.Equal(t, "", curl.appleActivityType())
+
+	//explicit mappings win
+	curl.GarminCategory = "unknown"
+	assert.Equal(t, "unknown", curl.garminCategory())
+}
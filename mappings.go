@@ -0,0 +1,310 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/viper"
+)
+
+// An activity the user has performed, with how many sets of it they've logged
+type UnmappedActivity struct {
+	Id   int
+	Name string
+	Sets int
+}
+
+// The activities among these sets with no mapping for the target, most
+// performed first
+func UnmappedActivities(details []UserActivityDetail, exerciseMapper *ExerciseMapper, target string) (unmapped []UnmappedActivity) {
+	byId := map[int]*UnmappedActivity{}
+	for _, detail := range details {
+		if exerciseMapper.IsMapped(target, detail.Activity.Id) {
+			continue
+		}
+		activity, ok := byId[detail.Activity.Id]
+		if !ok {
+			activity = &UnmappedActivity{Id: detail.Activity.Id, Name: detail.Activity.Name}
+			byId[detail.Activity.Id] = activity
+		}
+		activity.Sets++
+	}
+
+	for _, activity := range byId {
+		unmapped = append(unmapped, *activity)
+	}
+	sort.Slice(unmapped, func(i, j int) bool {
+		if unmapped[i].Sets != unmapped[j].Sets {
+			return unmapped[i].Sets > unmapped[j].Sets
+		}
+		return unmapped[i].Id < unmapped[j].Id
+	})
+	return
+}
+
+func mappingTargetNames() []string {
+	names := []string{}
+	for name := range mappingTargets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Handles "mappings <command>", for maintaining exercise_mappings.toml and
+// the user's overrides of it in the db
+func runMappings(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: mappings unmapped|suggest|validate|import|export")
+	}
+	switch args[0] {
+	case "unmapped":
+		return runMappingsUnmapped(db, username, exerciseMapper, args[1:])
+	case "suggest":
+		return runMappingsSuggest(exerciseMapper, args[1:])
+	case "validate":
+		return runMappingsValidate(db, username, exerciseMapper, args[1:])
+	case "import":
+		return runMappingsImport(db, username, args[1:])
+	case "export":
+		return runMappingsExport(db, username, args[1:])
+	}
+	return fmt.Errorf("unknown mappings command %s", args[0])
+}
+
+// Handles "mappings unmapped -target=<app>": every activity the user has
+// performed that has no mapping for the target, with set counts
+func runMappingsUnmapped(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	unmappedFlags := flag.NewFlagSet("mappings unmapped", flag.ExitOnError)
+	target := unmappedFlags.String("target", "", "Mapping to check: "+strings.Join(mappingTargetNames(), ", "))
+	filterFlags := addFilterFlags(unmappedFlags)
+	unmappedFlags.Parse(args)
+
+	if _, ok := mappingTargets[*target]; !ok {
+		unmappedFlags.PrintDefaults()
+		return fmt.Errorf("unknown mapping target %q", *target)
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+
+	err, user := GetUserByUsername(db, username)
+	if nil != err {
+		return err
+	}
+	err, details := GetUserActivityDetails(db, user.Id, filter)
+	if nil != err {
+		return err
+	}
+
+	unmapped := UnmappedActivities(details, exerciseMapper, *target)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FITOCRACY ID\tSETS\tNAME")
+	sets := 0
+	for _, activity := range unmapped {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", activity.Id, activity.Sets, activity.Name)
+		sets += activity.Sets
+	}
+	w.Flush()
+	fmt.Printf("%d exercises (%d sets) have no %s mapping\n", len(unmapped), sets, *target)
+	return nil
+}
+
+// Handles "mappings suggest -target=<app> -catalog=<file>": ranked catalog
+// candidates for every mapping entry missing the target, optionally written
+// into a patched copy of the mapping file for review
+func runMappingsSuggest(exerciseMapper *ExerciseMapper, args []string) error {
+	targets := []string{}
+	for name := range suggestionFields {
+		targets = append(targets, name)
+	}
+	sort.Strings(targets)
+
+	suggestFlags := flag.NewFlagSet("mappings suggest", flag.ExitOnError)
+	target := suggestFlags.String("target", "", "Mapping to suggest: "+strings.Join(targets, ", "))
+	catalogFile := suggestFlags.String("catalog", "", "The target app's exercise list, as a JSON or CSV file")
+	top := suggestFlags.Int("top", 3, "Number of candidates to list per exercise")
+	minScore := suggestFlags.Float64("min-score", 0.6, "Lowest score that will be written to the patched mapping file")
+	patch := suggestFlags.String("patch", "", "Write a copy of the mapping file with the best candidates filled in")
+	suggestFlags.Parse(args)
+
+	if _, ok := suggestionFields[*target]; !ok {
+		suggestFlags.PrintDefaults()
+		return fmt.Errorf("unknown suggestion target %q", *target)
+	}
+	catalog, err := LoadCatalog(*catalogFile)
+	if err != nil {
+		return err
+	}
+
+	chosen := map[int]Suggestion{}
+	for _, e := range exerciseMapper.exercises {
+		if mappingTargets[*target](e) {
+			continue
+		}
+		fmt.Printf("[%d] %s\n", e.FitocracyId, e.FitocracyName)
+		suggestions := SuggestMappings(e.FitocracyName, catalog, *top)
+		if len(suggestions) == 0 {
+			fmt.Println("    no candidates")
+		}
+		for _, suggestion := range suggestions {
+			fmt.Printf("    %.2f  %d  %s\n", suggestion.Score, suggestion.Entry.Id, suggestion.Entry.Name)
+		}
+		if len(suggestions) > 0 && suggestions[0].Score >= *minScore {
+			chosen[e.FitocracyId] = suggestions[0]
+		}
+	}
+
+	if *patch != "" {
+		mappings, err := ioutil.ReadFile(viper.GetString("exercise_mappings"))
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(*patch, []byte(PatchMappings(string(mappings), *target, chosen)), 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d suggested %s mappings to %s\n", len(chosen), *target, *patch)
+	}
+	return nil
+}
+
+// Handles "mappings validate [-target=<app> -catalog=<file> -strict]": lists
+// every problem with the mapping file, including activities in the db that
+// have no entry at all when the user has synced, and fails if there are any
+// so it can gate CI.
+// Many-to-one mappings are only warnings unless -strict is set.
+func runMappingsValidate(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	validateFlags := flag.NewFlagSet("mappings validate", flag.ExitOnError)
+	target := validateFlags.String("target", "", "Check this target's mappings against -catalog: "+strings.Join(mappingKeyTargets(), ", "))
+	catalogFile := validateFlags.String("catalog", "", "The target app's exercise list, as a JSON or CSV file")
+	strict := validateFlags.Bool("strict", false, "Fail when several exercises are mapped to the same target exercise")
+	validateFlags.Parse(args)
+
+	//check the file itself, since duplicates are gone once it's in the db
+	exercises, err := LoadMappingFile(viper.GetString("exercise_mappings"))
+	if err != nil {
+		return err
+	}
+	problems := ValidateMappings(exercises)
+	collisions := MappingCollisions(exercises)
+	if *strict {
+		problems = append(problems, collisions...)
+	} else {
+		for _, collision := range collisions {
+			fmt.Println("warning: " + collision)
+		}
+	}
+
+	if *catalogFile != "" {
+		if _, ok := mappingKeys[*target]; !ok {
+			validateFlags.PrintDefaults()
+			return fmt.Errorf("unknown catalog target %q", *target)
+		}
+		catalog, err := LoadCatalog(*catalogFile)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, ValidateMappingsAgainstCatalog(exercises, *target, catalog)...)
+	}
+
+	//without a synced user (e.g. in CI) there's no history to check against
+	err, user := GetUserByUsername(db, username)
+	switch {
+	case err == sql.ErrNoRows:
+		fmt.Printf("note: no synced user %q, so exercises you've performed weren't checked\n", username)
+	case nil != err:
+		return err
+	default:
+		err, details := GetUserActivityDetails(db, user.Id, ActivityFilter{})
+		if nil != err {
+			return err
+		}
+		reported := map[int]bool{}
+		for _, detail := range details {
+			if _, ok := exerciseMapper.ByFitocracyId[detail.Activity.Id]; !ok && !reported[detail.Activity.Id] {
+				reported[detail.Activity.Id] = true
+				problems = append(problems, fmt.Sprintf("[%d] %s has been performed but has no mapping entry", detail.Activity.Id, detail.Activity.Name))
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found in %s", len(problems), viper.GetString("exercise_mappings"))
+	}
+	fmt.Printf("%s is valid\n", viper.GetString("exercise_mappings"))
+	return nil
+}
+
+// Handles "mappings import -file=<toml>": stores the file's entries as the
+// user's overrides of exercise_mappings.toml, which survive the file being
+// synced into the db again on every run
+func runMappingsImport(db *sqlx.DB, username string, args []string) error {
+	importFlags := flag.NewFlagSet("mappings import", flag.ExitOnError)
+	file := importFlags.String("file", "", "A mapping file in the exercise_mappings.toml format")
+	replace := importFlags.Bool("replace", false, "Drop your existing overrides instead of adding to them")
+	importFlags.Parse(args)
+
+	if *file == "" {
+		importFlags.PrintDefaults()
+		return fmt.Errorf("a mapping file to import is required")
+	}
+	err, user := GetUserByUsername(db, username)
+	if nil != err {
+		return err
+	}
+	exercises, err := LoadMappingFile(*file)
+	if err != nil {
+		return err
+	}
+	err = SaveExerciseMappings(db, user.Id, exercises, *replace)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d mappings from %s\n", len(exercises), *file)
+	return nil
+}
+
+// Handles "mappings export -out=<toml>": writes the mappings in effect for
+// the user, or with -overrides just their own, in the exercise_mappings.toml
+// format so they can be edited and imported again
+func runMappingsExport(db *sqlx.DB, username string, args []string) error {
+	exportFlags := flag.NewFlagSet("mappings export", flag.ExitOnError)
+	out := exportFlags.String("out", "", "File to write, instead of stdout")
+	overrides := exportFlags.Bool("overrides", false, "Only export your overrides of the shared mappings")
+	exportFlags.Parse(args)
+
+	err, user := GetUserByUsername(db, username)
+	if nil != err {
+		return err
+	}
+	var exercises []Exercise
+	if *overrides {
+		err, exercises = GetExerciseMappingOverrides(db, user.Id)
+	} else {
+		err, exercises = GetExerciseMappings(db, user.Id)
+	}
+	if nil != err {
+		return err
+	}
+	encoded, err := EncodeMappings(exercises)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(encoded)
+		return err
+	}
+	return ioutil.WriteFile(*out, encoded, 0644)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// Exports strength sets as MyFitnessPal logs them: one entry per exercise per
+// day with a set count and a single reps/weight per set. Consecutive sets with
+// the same reps and weight are collapsed into one entry; anything else starts
+// a new entry for the same exercise, which MFP allows. Cardio, including
+// exercises MFP logs as cardio (mfp_type = "cardio"), has no place in a
+// strength log and is left out.
+type MFPExporter struct {
+	WeightUnits string
+}
+
+type mfpEntry struct {
+	date     string
+	exercise Exercise
+	sets     int
+	reps     float64
+	weight   float64
+}
+
+func (m MFPExporter) FileExtension() string {
+	return "csv"
+}
+
+func (m MFPExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	entries := []*mfpEntry{}
+
+	var last *mfpEntry
+	for _, detail := range details {
+		e := exerciseMapper.ByFitocracyId[detail.Activity.Id]
+		if e.MFPId <= 0 || e.MFPType == "cardio" || !detail.IsStrength() {
+			continue
+		}
+
+		date := detail.PerformedAt.Format("2006-01-02")
+		weight := ConvertWeight(detail.Weight, detail.Units, m.WeightUnits)
+		if last != nil && last.date == date && last.exercise.MFPId == e.MFPId && last.reps == detail.Reps && last.weight == weight {
+			last.sets++
+			continue
+		}
+		last = &mfpEntry{date: date, exercise: e, sets: 1, reps: detail.Reps, weight: weight}
+		entries = append(entries, last)
+	}
+
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"Date", "Exercise", "MFP Id", "Sets", "Reps/Set", "Weight/Set", "Units"}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err := csvWriter.Write([]string{
+			entry.date,
+			entry.exercise.MFPName,
+			strconv.Itoa(entry.exercise.MFPId),
+			strconv.Itoa(entry.sets),
+			strconv.FormatFloat(entry.reps, 'f', -1, 64),
+			strconv.FormatFloat(entry.weight, 'f', 2, 64),
+			m.WeightUnits,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func (m MFPExporter) MappingTarget() string {
+	return "mfp"
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFPExport(t *testing.T) {
+	mapper := NewExerciseMapper([]Exercise{
+		{FitocracyId: 2, FitocracyName: "Barbell Squat", MFPId: 289, MFPName: "Squat"},
+		{FitocracyId: 331, FitocracyName: "Basketball", MFPId: 12, MFPName: "Basketball, nongame, general", MFPType: "cardio"},
+		{FitocracyId: 518, FitocracyName: "Running", MFPId: 127},
+	})
+	run := testSet(6, 1, 518, 0, 0, "")
+	run.Duration = 1800
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 2, 5, 100, "kg"),
+		testSet(3, 1, 2, 3, 110, "kg"),
+		testSet(4, 1, 2, 5, 100, "kg"), //same as the first sets, but not consecutive
+		testSet(5, 1, 331, 1, 30, "min"),
+		run,
+		testSet(7, 1, 518, 1, 3.1, "mi"), //cardio synced without a distance
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, MFPExporter{WeightUnits: "kg"}.Export(&out, details, mapper))
+	assert.Equal(t, `Date,Exercise,MFP Id,Sets,Reps/Set,Weight/Set,Units
+2016-01-01,Squat,289,2,5,100.00,kg
+2016-01-01,Squat,289,1,3,110.00,kg
+2016-01-01,Squat,289,1,5,100.00,kg
+`, out.String())
+}
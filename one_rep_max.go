@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Estimates the weight that could be lifted for a single rep from a set of
+// several. Sets of zero reps estimate nothing.
+type OneRepMaxFormula func(weight float64, reps float64) float64
+
+var oneRepMaxFormulas = map[string]OneRepMaxFormula{
+	"epley":    EstimateOneRepMax,
+	"brzycki":  brzycki,
+	"lombardi": lombardi,
+}
+
+func oneRepMaxFormulaNames() []string {
+	names := []string{}
+	for name := range oneRepMaxFormulas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Estimate the weight that could be lifted for a single rep from a set of
+// several, using Epley's formula. Sets of zero reps estimate nothing.
+func EstimateOneRepMax(weight float64, reps float64) float64 {
+	if reps <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	return weight * (1 + reps/30)
+}
+
+// Brzycki's formula, which heads to infinity as reps approach 37, so longer
+// sets estimate nothing
+func brzycki(weight float64, reps float64) float64 {
+	if reps <= 0 || reps >= 37 {
+		return 0
+	}
+	return weight * 36 / (37 - reps)
+}
+
+func lombardi(weight float64, reps float64) float64 {
+	if reps <= 0 {
+		return 0
+	}
+	return weight * math.Pow(reps, 0.1)
+}
+
+// A strength set with its weight in the units being reported in and its
+// estimated one rep max
+type SetEstimate struct {
+	UserActivityDetail
+	Weight    float64
+	OneRepMax float64
+}
+
+// Estimates for every weighted strength set, in the order given. Weights are
+// converted to units.
+func EstimateSets(details []UserActivityDetail, formula OneRepMaxFormula, units string) (estimates []SetEstimate) {
+	for _, detail := range details {
+		if detail.IsCardio() || detail.Reps <= 0 || detail.UserActivity.Weight <= 0 || !isWeightUnit(detail.Units) {
+			continue
+		}
+		weight := ConvertWeight(detail.UserActivity.Weight, detail.Units, units)
+		estimates = append(estimates, SetEstimate{detail, weight, formula(weight, detail.Reps)})
+	}
+	return
+}
+
+// The estimate of the best set of each exercise in each workout, in the order
+// the workouts were performed
+func BestSetPerWorkout(estimates []SetEstimate) (best []SetEstimate) {
+	type key struct{ workout, activity int }
+	index := map[key]int{}
+	for _, estimate := range estimates {
+		k := key{estimate.FitocracyGroupId, estimate.Activity.Id}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(best)
+			best = append(best, estimate)
+		} else if estimate.OneRepMax > best[i].OneRepMax {
+			best[i] = estimate
+		}
+	}
+	return
+}
+
+// An all-time best estimated one rep max, and the one it beat
+type OneRepMaxRecord struct {
+	SetEstimate
+	Previous float64
+}
+
+// The sets that raised each exercise's all-time best estimate, in order
+func OneRepMaxProgression(estimates []SetEstimate) (records []OneRepMaxRecord) {
+	best := map[int]float64{}
+	for _, estimate := range estimates {
+		previous, ok := best[estimate.Activity.Id]
+		if ok && estimate.OneRepMax <= previous {
+			continue
+		}
+		best[estimate.Activity.Id] = estimate.OneRepMax
+		records = append(records, OneRepMaxRecord{estimate, previous})
+	}
+	return
+}
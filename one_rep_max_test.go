@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneRepMaxFormulas(t *testing.T) {
+	assert.InDelta(t, 116.7, EstimateOneRepMax(100, 5), 0.05)
+	assert.InDelta(t, 112.5, brzycki(100, 5), 0.05)
+	assert.InDelta(t, 117.5, lombardi(100, 5), 0.05)
+	for name, formula := range oneRepMaxFormulas {
+		assert.Equal(t, 100.0, formula(100, 1), name)
+		assert.Equal(t, 0.0, formula(100, 0), name)
+	}
+	assert.Equal(t, 0.0, brzycki(100, 40))
+}
+
+func testSet(id int, workout int, activity int, reps float64, weight float64, units string) UserActivityDetail {
+	return UserActivityDetail{
+		UserActivity: &UserActivity{Id: id, FitocracyGroupId: workout, Reps: reps, Weight: weight, Units: units,
+			PerformedAt: time.Date(2016, 1, workout, 12, 0, id, 0, time.UTC)},
+		Activity: &Activity{Id: activity, Name: "Barbell Squat"},
+	}
+}
+
+func TestOneRepMaxProgression(t *testing.T) {
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 2, 3, 110, "kg"),
+		testSet(3, 2, 2, 5, 225, "lb"), //102.1kg x 5, not a new best
+		testSet(4, 3, 2, 1, 130, "kg"),
+		testSet(5, 3, 2, 30, 0, ""),    //bodyweight, so nothing to estimate
+		testSet(6, 3, 2, 1, 45, "min"), //cardio synced without a duration
+	}
+	estimates := EstimateSets(details, EstimateOneRepMax, "kg")
+	assert.Len(t, estimates, 4)
+	assert.InDelta(t, 102.1, estimates[2].Weight, 0.05)
+
+	best := BestSetPerWorkout(estimates)
+	assert.Equal(t, []int{2, 3, 4}, []int{best[0].UserActivity.Id, best[1].UserActivity.Id, best[2].UserActivity.Id})
+
+	progression := OneRepMaxProgression(best)
+	assert.Equal(t, 2, len(progression))
+	assert.Equal(t, 4, progression[1].UserActivity.Id)
+	assert.InDelta(t, 121.0, progression[1].Previous, 0.05)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/viper"
+)
+
+// Progression settings from the [progression] section of config.toml, with
+// increments suited to weight_units where they're not set
+func configuredProgressionOptions(formula OneRepMaxFormula) ProgressionOptions {
+	options := ProgressionOptions{
+		Units:          viper.GetString("weight_units"),
+		UpperIncrement: 5,
+		LowerIncrement: 10,
+		Rounding:       5,
+		TrainingMax:    0.9,
+		Formula:        formula,
+	}
+	if isKilograms(options.Units) {
+		options.UpperIncrement, options.LowerIncrement, options.Rounding = 2.5, 5, 2.5
+	}
+	if viper.IsSet("progression.upper_increment") {
+		options.UpperIncrement = viper.GetFloat64("progression.upper_increment")
+	}
+	if viper.IsSet("progression.lower_increment") {
+		options.LowerIncrement = viper.GetFloat64("progression.lower_increment")
+	}
+	if viper.IsSet("progression.rounding") {
+		options.Rounding = viper.GetFloat64("progression.rounding")
+	}
+	if viper.IsSet("progression.training_max") {
+		options.TrainingMax = viper.GetFloat64("progression.training_max") / 100
+	}
+	return options
+}
+
+// Parse a rep target like "5" or a range like "8-12"
+func parseRepRange(s string) (min int, max int, err error) {
+	parts := strings.SplitN(s, "-", 2)
+	min, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	max = min
+	if err == nil && len(parts) == 2 {
+		max, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+	}
+	if err != nil || min <= 0 || max < min {
+		return 0, 0, fmt.Errorf("invalid rep target %q, expected e.g. 5 or 8-12", s)
+	}
+	return
+}
+
+// The plans as the sets of one workout starting at start, a minute apart, for
+// handing to an Exporter
+func plannedDetails(plans []ExercisePlan, start time.Time, units string) (details []UserActivityDetail) {
+	performedAt := start
+	for _, plan := range plans {
+		for _, set := range plan.Sets {
+			details = append(details, UserActivityDetail{
+				UserActivity: &UserActivity{
+					ActivityId:  plan.Activity.Id,
+					Units:       units,
+					Reps:        set.Reps,
+					Weight:      set.Weight,
+					PerformedAt: performedAt,
+				},
+				Activity:    plan.Activity,
+				WorkoutName: "Planned " + plan.Scheme + " session",
+			})
+			performedAt = performedAt.Add(assumedSetDuration)
+		}
+	}
+	return
+}
+
+// Handles "plan -scheme=<scheme> --exercise=<exercise>": suggests the next
+// session of each exercise from its recent sets, printed as a report or
+// exported with -export like the export command's targets
+func runPlan(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	planFlags := flag.NewFlagSet("plan", flag.ExitOnError)
+	scheme := planFlags.String("scheme", "linear", "Progression scheme: "+strings.Join(progressionSchemes, ", "))
+	repTarget := planFlags.String("reps", "", "Reps per set for linear (default 5), or a range for double progression (default 8-12)")
+	sets := planFlags.Int("sets", 3, "Working sets for linear and double progression")
+	week := planFlags.Int("week", 1, "Week of the 5/3/1 cycle, 1-4")
+	date := planFlags.String("date", "", "Date of the planned session (YYYY-MM-DD, defaults to tomorrow)")
+	exportTarget := planFlags.String("export", "", "Write the plan for this export target instead of printing it")
+	out := planFlags.String("out", "", "File to write with -export (defaults to plan.<extension>)")
+	format := addReportFormatFlag(planFlags)
+	formulaFlag := addOneRepMaxFormulaFlag(planFlags)
+	filterFlags := addFilterFlags(planFlags)
+	planFlags.Parse(args)
+
+	formula, err := formulaFlag()
+	if err != nil {
+		return err
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	if len(filter.Exercises) == 0 {
+		planFlags.PrintDefaults()
+		return fmt.Errorf("at least one --exercise to plan is required")
+	}
+	options := configuredProgressionOptions(formula)
+	options.Sets = *sets
+	options.Week = *week
+	if *repTarget == "" {
+		*repTarget = "5"
+		if *scheme == "double" {
+			*repTarget = "8-12"
+		}
+	}
+	if options.MinReps, options.MaxReps, err = parseRepRange(*repTarget); err != nil {
+		return err
+	}
+
+	location := configuredLocation()
+	now := time.Now().In(location)
+	start := time.Date(now.Year(), now.Month(), now.Day()+1, 12, 0, 0, 0, location)
+	if *date != "" {
+		day, err := time.ParseInLocation(filterDateFormat, *date, location)
+		if err != nil {
+			return fmt.Errorf("invalid -date: %s", err)
+		}
+		start = day.Add(12 * time.Hour)
+	}
+
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+	sessions := exerciseSessions(EstimateSets(details, formula, options.Units))
+	plans := []ExercisePlan{}
+	planned := map[int]bool{}
+	for _, detail := range details {
+		id := detail.Activity.Id
+		if planned[id] || len(sessions[id]) == 0 {
+			continue
+		}
+		planned[id] = true
+		plan, err := PlanNextSession(sessions[id], exerciseMapper.ByFitocracyId[id], *scheme, options)
+		if err != nil {
+			return err
+		}
+		plans = append(plans, plan)
+	}
+	if len(plans) == 0 {
+		return fmt.Errorf("no weighted sets of those exercises to plan from")
+	}
+
+	if *exportTarget != "" {
+		exporter, ok := exporters()[*exportTarget]
+		if !ok {
+			return fmt.Errorf("unknown export target %q", *exportTarget)
+		}
+		if *out == "" {
+			*out = "plan." + exporter.FileExtension()
+		}
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		err = exporter.Export(f, plannedDetails(plans, start, options.Units), exerciseMapper)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+
+	report := NewReport(location, "date", "exercise_id", "exercise", "set", "reps", "weight", "units", "note")
+	for _, plan := range plans {
+		for i, set := range plan.Sets {
+			reps := strconv.FormatFloat(set.Reps, 'f', -1, 64)
+			if set.AMRAP {
+				reps += "+"
+			}
+			note := ""
+			if i == 0 {
+				note = plan.Note
+			}
+			report.AddRow(start, plan.Activity.Id, plan.Activity.Name, i+1, reps, set.Weight, options.Units, note)
+		}
+	}
+	return report.Write(os.Stdout, *format)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"time"
+)
+
+// How an exercise's metric has gone over the most recent window
+const (
+	trendImproving  = "improving"
+	trendPlateau    = "plateau"
+	trendRegression = "regression"
+)
+
+type PlateauOptions struct {
+	AsOf        time.Time     //end of the window
+	Window      time.Duration //length of the window
+	Drop        float64       //fraction below the previous window that counts as a regression
+	MinSessions int           //sessions in the window needed to judge it
+}
+
+// An exercise's metric in the window ending at AsOf, against its best
+// before the window and its best in the window before that
+type Trend struct {
+	Id                 int
+	Name               string
+	Status             string
+	Sessions           int
+	WindowBest         float64
+	PriorBest          float64
+	PreviousWindowBest float64
+	LastImproved       time.Time
+	LastPerformed      time.Time
+}
+
+// The change from the best before the window, as a percentage
+func (t Trend) ChangeFromBest() float64 {
+	return (t.WindowBest - t.PriorBest) / t.PriorBest * 100
+}
+
+// The change from the window before, as a percentage, or 0 when there was
+// nothing then
+func (t Trend) ChangeFromPreviousWindow() float64 {
+	if t.PreviousWindowBest == 0 {
+		return 0
+	}
+	return (t.WindowBest - t.PreviousWindowBest) / t.PreviousWindowBest * 100
+}
+
+// Judge each series over the window. A window whose best doesn't beat the
+// best before it is a plateau, and one whose best is more than Drop below the
+// window before's is a regression. Exercises with too few sessions in the
+// window, or none before it, are left out.
+func Trends(series []ChartSeries, options PlateauOptions) (trends []Trend) {
+	start := options.AsOf.Add(-options.Window)
+	previousStart := start.Add(-options.Window)
+	for _, s := range series {
+		trend := Trend{Id: s.Id, Name: s.Name}
+		best := 0.0
+		for _, point := range s.Points {
+			if point.Time.After(options.AsOf) {
+				continue
+			}
+			trend.LastPerformed = point.Time
+			if point.Value > best {
+				best = point.Value
+				trend.LastImproved = point.Time
+			}
+			if point.Time.After(start) {
+				trend.Sessions++
+				if point.Value > trend.WindowBest {
+					trend.WindowBest = point.Value
+				}
+				continue
+			}
+			if point.Value > trend.PriorBest {
+				trend.PriorBest = point.Value
+			}
+			if point.Time.After(previousStart) && point.Value > trend.PreviousWindowBest {
+				trend.PreviousWindowBest = point.Value
+			}
+		}
+		if trend.Sessions < options.MinSessions || trend.PriorBest == 0 {
+			continue
+		}
+
+		switch {
+		case trend.PreviousWindowBest > 0 && trend.WindowBest < trend.PreviousWindowBest*(1-options.Drop):
+			trend.Status = trendRegression
+		case trend.WindowBest <= trend.PriorBest:
+			trend.Status = trendPlateau
+		default:
+			trend.Status = trendImproving
+		}
+		trends = append(trends, trend)
+	}
+	return
+}
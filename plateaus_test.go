@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func weeklyPoints(values ...float64) (points []ChartPoint) {
+	start := time.Date(2016, 1, 4, 0, 0, 0, 0, time.UTC)
+	for i, value := range values {
+		points = append(points, ChartPoint{start.AddDate(0, 0, 7*i), value})
+	}
+	return
+}
+
+func TestTrends(t *testing.T) {
+	series := []ChartSeries{
+		{Id: 1, Name: "Bench", Points: weeklyPoints(100, 105, 110, 110, 108, 109, 110)},
+		{Id: 2, Name: "Squat", Points: weeklyPoints(100, 110, 120, 130, 100, 95, 98)},
+		{Id: 3, Name: "Deadlift", Points: weeklyPoints(100, 110, 120, 130, 135, 140, 145)},
+		{Id: 4, Name: "Row", Points: weeklyPoints(100, 110)},
+	}
+	options := PlateauOptions{
+		AsOf:        series[0].Points[6].Time,
+		Window:      3 * 7 * 24 * time.Hour,
+		Drop:        0.1,
+		MinSessions: 3,
+	}
+	trends := Trends(series, options)
+	assert.Len(t, trends, 3)
+
+	assert.Equal(t, trendPlateau, trends[0].Status)
+	assert.Equal(t, 110.0, trends[0].PriorBest)
+	assert.Equal(t, series[0].Points[2].Time, trends[0].LastImproved)
+
+	assert.Equal(t, trendRegression, trends[1].Status)
+	assert.InDelta(t, -23.1, trends[1].ChangeFromPreviousWindow(), 0.05)
+
+	assert.Equal(t, trendImproving, trends[2].Status)
+	assert.InDelta(t, 11.5, trends[2].ChangeFromBest(), 0.05)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Fitocracy scores a set by the work done in it, scaled by its activity's
+// multiplier. We measure work the way the set was logged: weight x reps
+// (in pounds), bare reps, or minutes (kilometers when untimed) of cardio.
+const (
+	workWeighted = "weighted"
+	workReps     = "reps"
+	workTime     = "time"
+	workDistance = "distance"
+)
+
+// Points per unit of work at a multiplier of 1, for activities we have no
+// scored history of. Rough fits to Fitocracy's scores.
+var defaultPointRates = map[string]float64{
+	workWeighted: 0.1,
+	workReps:     1.8,
+	workTime:     5,
+	workDistance: 30,
+}
+
+// How a set's work is measured, and how much of it was done. Sets without
+// any effort score nothing.
+func setWork(u UserActivity) (kind string, work float64) {
+	switch {
+	case u.Duration > 0:
+		return workTime, u.Duration / 60
+	case u.Distance > 0:
+		return workDistance, u.Distance / 1000
+	case u.Weight > 0 && u.Reps > 0 && isWeightUnit(u.Units):
+		return workWeighted, ConvertWeight(u.Weight, u.Units, "lbs") * u.Reps
+	case u.Reps > 0:
+		return workReps, u.Reps
+	}
+	return "", 0
+}
+
+type pointRateKey struct {
+	activity int
+	kind     string
+}
+
+// Estimates Fitocracy points for sets. Rates are calibrated per activity
+// from the points Fitocracy gave its synced sets; anything else scores at
+// the default rate for its kind of work times its activity's multiplier.
+type PointsModel struct {
+	rates map[pointRateKey]float64
+}
+
+// Calibrate from every set with recorded points, using the median points
+// per unit of work so the odd bonus or rounding doesn't skew the rate
+func CalibratePoints(details []UserActivityDetail) PointsModel {
+	ratios := map[pointRateKey][]float64{}
+	for _, detail := range details {
+		kind, work := setWork(*detail.UserActivity)
+		if detail.Points <= 0 || work <= 0 {
+			continue
+		}
+		k := pointRateKey{detail.Activity.Id, kind}
+		ratios[k] = append(ratios[k], float64(detail.Points)/work)
+	}
+
+	model := PointsModel{rates: map[pointRateKey]float64{}}
+	for k, r := range ratios {
+		sort.Float64s(r)
+		if len(r)%2 == 1 {
+			model.rates[k] = r[len(r)/2]
+		} else {
+			model.rates[k] = (r[len(r)/2-1] + r[len(r)/2]) / 2
+		}
+	}
+	return model
+}
+
+// The points we'd expect Fitocracy to give a set
+func (m PointsModel) Estimate(detail UserActivityDetail) int {
+	kind, work := setWork(*detail.UserActivity)
+	if work <= 0 {
+		return 0
+	}
+	rate, ok := m.rates[pointRateKey{detail.Activity.Id, kind}]
+	if !ok {
+		multiplier := detail.Activity.Multiplier
+		if multiplier <= 0 {
+			multiplier = 1
+		}
+		rate = defaultPointRates[kind] * multiplier
+	}
+	return int(math.Round(rate * work))
+}
+
+// A set's score: what Fitocracy gave it, or our estimate for sets it never
+// scored, so history and newer training stay comparable
+func (m PointsModel) Score(detail UserActivityDetail) int {
+	if detail.Points > 0 {
+		return detail.Points
+	}
+	return m.Estimate(detail)
+}
+
+// Points for a workout, week or exercise. Recorded only counts sets that
+// Fitocracy scored; estimated counts every set.
+type PointsRow struct {
+	Time      time.Time
+	Id        int
+	Name      string
+	Sets      int
+	Recorded  int
+	Estimated int
+	Score     int
+}
+
+var pointsGroupings = []string{"workout", "week", "month", "exercise"}
+
+// Total points by workout, week, month or exercise, in the order trained
+func Points(details []UserActivityDetail, model PointsModel, by string, location *time.Location) ([]PointsRow, error) {
+	type key struct {
+		period time.Time
+		id     int
+	}
+	rows := map[key]*PointsRow{}
+	order := []key{}
+	for _, detail := range details {
+		var k key
+		name := ""
+		switch by {
+		case "workout":
+			k = key{id: detail.FitocracyGroupId}
+			name = detail.WorkoutName
+		case "week", "month":
+			k = key{period: periodStart(detail.PerformedAt, by, location)}
+		case "exercise":
+			k = key{id: detail.Activity.Id}
+			name = detail.Activity.Name
+		default:
+			return nil, fmt.Errorf("unknown points grouping %q", by)
+		}
+		row, ok := rows[k]
+		if !ok {
+			row = &PointsRow{Time: detail.PerformedAt, Id: k.id, Name: name}
+			if by == "week" || by == "month" {
+				row.Time = k.period
+			}
+			rows[k] = row
+			order = append(order, k)
+		}
+		row.Sets++
+		row.Recorded += detail.Points
+		row.Estimated += model.Estimate(detail)
+		row.Score += model.Score(detail)
+	}
+
+	points := []PointsRow{}
+	for _, k := range order {
+		points = append(points, *rows[k])
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, nil
+}
+
+// How far off the estimates are for sets Fitocracy scored: the mean absolute
+// difference as a fraction of the recorded points, and how many sets that was
+func PointsEstimateError(details []UserActivityDetail, model PointsModel) (meanError float64, sets int) {
+	for _, detail := range details {
+		if detail.Points <= 0 {
+			continue
+		}
+		meanError += math.Abs(float64(model.Estimate(detail)-detail.Points)) / float64(detail.Points)
+		sets++
+	}
+	if sets > 0 {
+		meanError /= float64(sets)
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointsModel(t *testing.T) {
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "lbs"),
+		testSet(2, 1, 2, 5, 100, "lbs"),
+		testSet(3, 1, 2, 5, 100, "lbs"),
+		testSet(4, 2, 2, 10, 100, "lbs"),
+		testSet(5, 2, 1, 5, 100, "kg"),
+	}
+	details[0].Points = 60
+	details[1].Points = 60
+	details[2].Points = 90 //a bonus shouldn't move the median
+	details[4].Activity = &Activity{Id: 1, Name: "Barbell Bench Press", Multiplier: 2}
+
+	model := CalibratePoints(details)
+	assert.Equal(t, 120, model.Estimate(details[3]))
+	//no history for the bench, so 0.1 per lb x rep, doubled
+	assert.Equal(t, 220, model.Estimate(details[4]))
+	assert.Equal(t, 90, model.Score(details[2]))
+	assert.Equal(t, 120, model.Score(details[3]))
+
+	meanError, sets := PointsEstimateError(details, model)
+	assert.Equal(t, 3, sets)
+	assert.InDelta(t, 1.0/9, meanError, 0.001)
+
+	points, err := Points(details, model, "workout", time.UTC)
+	assert.NoError(t, err)
+	assert.Len(t, points, 2)
+	assert.Equal(t, PointsRow{Time: details[0].PerformedAt, Id: 1, Sets: 3, Recorded: 210, Estimated: 180, Score: 210}, points[0])
+	assert.Equal(t, 340, points[1].Score)
+
+	_, err = Points(details, model, "day", time.UTC)
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/viper"
+)
+
+// What the chart command can plot for an exercise, per workout
+var chartMetrics = map[string]string{
+	"top_set": "Top set weight",
+	"e1rm":    "Estimated 1RM",
+	"volume":  "Volume",
+}
+
+// A series per exercise of the metric's value in each workout the exercise
+// was performed in, in the order the exercises were first performed. e1RMs
+// are the same estimates as stats 1rm, so they don't add body weight.
+func ProgressSeries(details []UserActivityDetail, exerciseMapper *ExerciseMapper, metric string, formula OneRepMaxFormula, units string, bodyWeight float64) ([]ChartSeries, error) {
+	if _, ok := chartMetrics[metric]; !ok {
+		return nil, fmt.Errorf("unknown chart metric %q", metric)
+	}
+
+	type setValue struct {
+		detail UserActivityDetail
+		value  float64
+	}
+	values := []setValue{}
+	if metric == "e1rm" {
+		for _, estimate := range EstimateSets(details, formula, units) {
+			values = append(values, setValue{estimate.UserActivityDetail, estimate.OneRepMax})
+		}
+	} else {
+		for _, detail := range details {
+			if detail.IsCardio() {
+				continue
+			}
+			load := setLoad(detail, exerciseMapper.ByFitocracyId[detail.Activity.Id], units, bodyWeight)
+			if metric == "volume" {
+				load *= detail.Reps
+			}
+			values = append(values, setValue{detail, load})
+		}
+	}
+
+	type key struct{ workout, activity int }
+	series := []ChartSeries{}
+	seriesIndex := map[int]int{}
+	pointIndex := map[key]int{}
+	for _, v := range values {
+		detail, value := v.detail, v.value
+		if value <= 0 {
+			continue
+		}
+
+		s, ok := seriesIndex[detail.Activity.Id]
+		if !ok {
+			s = len(series)
+			seriesIndex[detail.Activity.Id] = s
+			series = append(series, ChartSeries{Id: detail.Activity.Id, Name: detail.Activity.Name})
+		}
+		k := key{detail.FitocracyGroupId, detail.Activity.Id}
+		p, ok := pointIndex[k]
+		if !ok {
+			pointIndex[k] = len(series[s].Points)
+			series[s].Points = append(series[s].Points, ChartPoint{detail.PerformedAt, value})
+			continue
+		}
+		point := &series[s].Points[p]
+		if metric == "volume" {
+			point.Value += value
+		} else if value > point.Value {
+			point.Value = value
+		}
+	}
+	return series, nil
+}
+
+// Handles "chart -metric=<metric> --exercise=<exercise> [-out=<file>]": an SVG
+// line chart of the metric per workout, with a line per exercise
+func runChart(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	metrics := []string{}
+	for metric := range chartMetrics {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	chartFlags := flag.NewFlagSet("chart", flag.ExitOnError)
+	metric := chartFlags.String("metric", "e1rm", "What to plot: "+strings.Join(metrics, ", "))
+	out := chartFlags.String("out", "", "File to write (defaults to <metric>.svg)")
+	formulaFlag := addOneRepMaxFormulaFlag(chartFlags)
+	filterFlags := addFilterFlags(chartFlags)
+	chartFlags.Parse(args)
+
+	formula, err := formulaFlag()
+	if err != nil {
+		return err
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	if len(filter.Exercises) == 0 {
+		chartFlags.PrintDefaults()
+		return fmt.Errorf("at least one --exercise to chart is required")
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+
+	units := viper.GetString("weight_units")
+	series, err := ProgressSeries(details, exerciseMapper, *metric, formula, units, viper.GetFloat64("body_weight"))
+	if err != nil {
+		chartFlags.PrintDefaults()
+		return err
+	}
+	location := configuredLocation()
+	names := []string{}
+	for _, s := range series {
+		names = append(names, s.Name)
+		for i := range s.Points {
+			s.Points[i].Time = wallClock(s.Points[i].Time, location)
+		}
+	}
+	chart := LineChart{
+		Title:  chartMetrics[*metric] + ": " + strings.Join(names, ", "),
+		YLabel: chartMetrics[*metric] + " (" + units + ")",
+		Series: series,
+	}
+
+	if *out == "" {
+		*out = *metric + ".svg"
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	err = chart.WriteSVG(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+var progressionSchemes = []string{"linear", "double", "531"}
+
+// The percentages of training max and reps for each week of a 5/3/1 cycle.
+// The last set of weeks one to three is as many reps as possible.
+var fiveThreeOneWeeks = [][]struct {
+	percent float64
+	reps    float64
+}{
+	{{0.65, 5}, {0.75, 5}, {0.85, 5}},
+	{{0.70, 3}, {0.80, 3}, {0.90, 3}},
+	{{0.75, 5}, {0.85, 3}, {0.95, 1}},
+	{{0.40, 5}, {0.50, 5}, {0.60, 5}},
+}
+
+// Failing the same weight this many sessions running earns a deload under
+// linear progression
+const (
+	linearFailuresBeforeDeload = 3
+	deloadFraction             = 0.9
+)
+
+type ProgressionOptions struct {
+	Units          string
+	UpperIncrement float64 //weight added to upper body lifts
+	LowerIncrement float64 //weight added to squats, hinges and lunges
+	Rounding       float64 //smallest weight change that can be loaded
+	Sets           int
+	MinReps        int     //the reps linear progression aims for, or the bottom of the double progression range
+	MaxReps        int     //the top of the double progression range
+	Week           int     //of the 5/3/1 cycle, 1-4
+	TrainingMax    float64 //fraction of the best recent estimated 1RM that 5/3/1 works from
+	Formula        OneRepMaxFormula
+}
+
+type PlannedSet struct {
+	Reps   float64
+	Weight float64
+	AMRAP  bool
+}
+
+// The sets suggested for an exercise's next session, and why
+type ExercisePlan struct {
+	Activity *Activity
+	Scheme   string
+	Sets     []PlannedSet
+	Note     string
+}
+
+// An exercise's weighted sets grouped by workout, in order
+func exerciseSessions(estimates []SetEstimate) map[int][][]SetEstimate {
+	sessions := map[int][][]SetEstimate{}
+	for _, estimate := range estimates {
+		id := estimate.Activity.Id
+		n := len(sessions[id])
+		if n > 0 && sessions[id][n-1][0].FitocracyGroupId == estimate.FitocracyGroupId {
+			sessions[id][n-1] = append(sessions[id][n-1], estimate)
+			continue
+		}
+		sessions[id] = append(sessions[id], []SetEstimate{estimate})
+	}
+	return sessions
+}
+
+// The heaviest weight in a session, and the reps of every set at it
+func topSets(session []SetEstimate) (weight float64, reps []float64) {
+	for _, set := range session {
+		weight = math.Max(weight, set.Weight)
+	}
+	for _, set := range session {
+		if set.Weight == weight {
+			reps = append(reps, set.Reps)
+		}
+	}
+	return
+}
+
+// Whether there were at least sets sets of at least reps reps
+func reachedReps(reps []float64, sets int, target float64) bool {
+	if len(reps) < sets {
+		return false
+	}
+	for _, r := range reps {
+		if r < target {
+			return false
+		}
+	}
+	return true
+}
+
+func isLowerBody(exercise Exercise) bool {
+	switch exercise.MovementPattern {
+	case "squat", "hinge", "lunge":
+		return true
+	}
+	return false
+}
+
+func (o ProgressionOptions) round(weight float64) float64 {
+	if o.Rounding <= 0 {
+		return weight
+	}
+	return math.Round(weight/o.Rounding) * o.Rounding
+}
+
+func repeatSets(sets int, reps float64, weight float64) (planned []PlannedSet) {
+	for i := 0; i < sets; i++ {
+		planned = append(planned, PlannedSet{Reps: reps, Weight: weight})
+	}
+	return
+}
+
+// Suggest the next session of an exercise from its past sessions
+func PlanNextSession(sessions [][]SetEstimate, exercise Exercise, scheme string, options ProgressionOptions) (ExercisePlan, error) {
+	if len(sessions) == 0 {
+		return ExercisePlan{}, fmt.Errorf("no weighted sets to plan from")
+	}
+	last := sessions[len(sessions)-1]
+	plan := ExercisePlan{Activity: last[0].Activity, Scheme: scheme}
+	increment := options.UpperIncrement
+	if isLowerBody(exercise) {
+		increment = options.LowerIncrement
+	}
+	weight, reps := topSets(last)
+
+	switch scheme {
+	case "linear":
+		target := float64(options.MinReps)
+		failures := 0
+		for i := len(sessions) - 1; i >= 0; i-- {
+			w, r := topSets(sessions[i])
+			if w != weight || reachedReps(r, options.Sets, target) {
+				break
+			}
+			failures++
+		}
+		switch {
+		case failures == 0:
+			plan.Sets = repeatSets(options.Sets, target, options.round(weight+increment))
+			plan.Note = fmt.Sprintf("hit %dx%g at %g last time, so add %g", options.Sets, target, weight, increment)
+		case failures >= linearFailuresBeforeDeload:
+			plan.Sets = repeatSets(options.Sets, target, options.round(weight*deloadFraction))
+			plan.Note = fmt.Sprintf("missed %dx%g at %g %d sessions running, so deload", options.Sets, target, weight, failures)
+		default:
+			plan.Sets = repeatSets(options.Sets, target, weight)
+			plan.Note = fmt.Sprintf("missed %dx%g at %g last time, so repeat it", options.Sets, target, weight)
+		}
+
+	case "double":
+		top := float64(options.MaxReps)
+		if reachedReps(reps, options.Sets, top) {
+			plan.Sets = repeatSets(options.Sets, float64(options.MinReps), options.round(weight+increment))
+			plan.Note = fmt.Sprintf("reached %g reps on every set at %g, so add %g and start again at %d", top, weight, increment, options.MinReps)
+			break
+		}
+		for i := 0; i < options.Sets; i++ {
+			next := float64(options.MinReps)
+			if i < len(reps) {
+				next = math.Min(top, math.Max(next, reps[i]+1))
+			}
+			plan.Sets = append(plan.Sets, PlannedSet{Reps: next, Weight: weight})
+		}
+		plan.Note = fmt.Sprintf("work towards %g reps on every set at %g", top, weight)
+
+	case "531":
+		if options.Week < 1 || options.Week > len(fiveThreeOneWeeks) {
+			return plan, fmt.Errorf("5/3/1 week must be 1 to %d", len(fiveThreeOneWeeks))
+		}
+		best := 0.0
+		for _, session := range sessions[max(0, len(sessions)-3):] {
+			for _, set := range session {
+				best = math.Max(best, options.Formula(set.Weight, set.Reps))
+			}
+		}
+		trainingMax := options.round(best * options.TrainingMax)
+		for i, set := range fiveThreeOneWeeks[options.Week-1] {
+			plan.Sets = append(plan.Sets, PlannedSet{
+				Reps:   set.reps,
+				Weight: options.round(trainingMax * set.percent),
+				AMRAP:  options.Week < 4 && i == 2,
+			})
+		}
+		plan.Note = fmt.Sprintf("week %d from a training max of %g (%g%% of the best recent e1RM, %g)", options.Week, trainingMax, options.TrainingMax*100, round(best, 1))
+
+	default:
+		return plan, fmt.Errorf("unknown progression scheme %q", scheme)
+	}
+	return plan, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSessions(sessions ...[]float64) [][]SetEstimate {
+	details := []UserActivityDetail{}
+	for i, session := range sessions {
+		weight := session[0]
+		for _, reps := range session[1:] {
+			details = append(details, testSet(len(details)+1, i+1, 2, reps, weight, "kg"))
+		}
+	}
+	return exerciseSessions(EstimateSets(details, EstimateOneRepMax, "kg"))[2]
+}
+
+func TestPlanNextSession(t *testing.T) {
+	options := ProgressionOptions{Units: "kg", UpperIncrement: 2.5, LowerIncrement: 5, Rounding: 2.5,
+		Sets: 3, MinReps: 5, MaxReps: 5, Week: 1, TrainingMax: 0.9, Formula: EstimateOneRepMax}
+	squat := Exercise{MovementPattern: "squat"}
+
+	plan, err := PlanNextSession(testSessions([]float64{100, 5, 5, 5}), squat, "linear", options)
+	assert.NoError(t, err)
+	assert.Equal(t, repeatSets(3, 5, 105), plan.Sets)
+
+	plan, _ = PlanNextSession(testSessions([]float64{100, 5, 5, 5}, []float64{105, 5, 5, 4}), Exercise{}, "linear", options)
+	assert.Equal(t, repeatSets(3, 5, 105), plan.Sets)
+
+	missed := []float64{105, 5, 4, 3}
+	plan, _ = PlanNextSession(testSessions(missed, missed, missed), squat, "linear", options)
+	assert.Equal(t, repeatSets(3, 5, 95), plan.Sets)
+
+	options.MinReps, options.MaxReps = 8, 12
+	plan, _ = PlanNextSession(testSessions([]float64{40, 12, 10, 9}), Exercise{}, "double", options)
+	assert.Equal(t, []PlannedSet{{12, 40, false}, {11, 40, false}, {10, 40, false}}, plan.Sets)
+	plan, _ = PlanNextSession(testSessions([]float64{40, 12, 12, 12}), Exercise{}, "double", options)
+	assert.Equal(t, repeatSets(3, 8, 42.5), plan.Sets)
+
+	//a 120kg e1RM gives a 107.5kg training max
+	plan, _ = PlanNextSession(testSessions([]float64{120, 1}), squat, "531", options)
+	assert.Equal(t, []PlannedSet{{5, 70, false}, {5, 80, false}, {5, 92.5, true}}, plan.Sets)
+
+	_, err = PlanNextSession(nil, squat, "linear", options)
+	assert.Error(t, err)
+}
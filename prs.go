@@ -0,0 +1,177 @@
+package main
+
+import (
+	"sort"
+)
+
+// The kinds of personal record we track. Rep maxes are the heaviest weight
+// lifted for at least that many reps; volume is the most weight moved (reps
+// x weight) in one exercise in one workout.
+const (
+	recordEstimatedOneRepMax = "e1rm"
+	recordVolume             = "volume"
+)
+
+var repMaxRecords = []struct {
+	kind string
+	reps float64
+}{
+	{"1rm", 1},
+	{"3rm", 3},
+	{"5rm", 5},
+	{"10rm", 10},
+}
+
+func recordKinds() []string {
+	kinds := []string{}
+	for _, repMax := range repMaxRecords {
+		kinds = append(kinds, repMax.kind)
+	}
+	return append(kinds, recordEstimatedOneRepMax, recordVolume)
+}
+
+// A set that beat an exercise's previous best of some kind. For volume
+// records it's the last set of the workout.
+type PersonalRecord struct {
+	SetEstimate
+	Kind     string
+	Value    float64
+	Previous float64
+}
+
+// Every personal record set by these sets, in the order they were set. The
+// first time an exercise is performed sets its first records.
+func PersonalRecords(estimates []SetEstimate) (records []PersonalRecord) {
+	type key struct {
+		activity int
+		kind     string
+	}
+	best := map[key]float64{}
+	check := func(estimate SetEstimate, kind string, value float64) {
+		k := key{estimate.Activity.Id, kind}
+		previous, ok := best[k]
+		if ok && value <= previous {
+			return
+		}
+		best[k] = value
+		records = append(records, PersonalRecord{estimate, kind, value, previous})
+	}
+
+	type session struct {
+		last   SetEstimate
+		volume float64
+	}
+	sessions := map[key]*session{}
+	finish := func(k key) {
+		if s, ok := sessions[k]; ok {
+			check(s.last, recordVolume, s.volume)
+			delete(sessions, k)
+		}
+	}
+
+	for _, estimate := range estimates {
+		for _, repMax := range repMaxRecords {
+			if estimate.Reps >= repMax.reps {
+				check(estimate, repMax.kind, estimate.Weight)
+			}
+		}
+		check(estimate, recordEstimatedOneRepMax, estimate.OneRepMax)
+
+		k := key{estimate.Activity.Id, ""}
+		s, ok := sessions[k]
+		if ok && s.last.FitocracyGroupId != estimate.FitocracyGroupId {
+			finish(k)
+			ok = false
+		}
+		if !ok {
+			s = &session{}
+			sessions[k] = s
+		}
+		s.last = estimate
+		s.volume += estimate.Reps * estimate.Weight
+	}
+
+	//volume records for each exercise's last workout, in the order performed
+	remaining := []key{}
+	for k := range sessions {
+		remaining = append(remaining, k)
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		return sessions[remaining[i]].last.PerformedAt.Before(sessions[remaining[j]].last.PerformedAt)
+	})
+	for _, k := range remaining {
+		finish(k)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].PerformedAt.Before(records[j].PerformedAt)
+	})
+	return
+}
+
+// The records set by sets the filter matches. Records have to be found in the
+// whole history first, or the first set in the range or workout always counts
+// as one.
+func RecordsIncluded(records []PersonalRecord, filter ActivityFilter) (included []PersonalRecord) {
+	for _, record := range records {
+		if filter.Matches(record.UserActivityDetail) {
+			included = append(included, record)
+		}
+	}
+	return
+}
+
+// The standing record of each kind for each exercise
+func CurrentRecords(records []PersonalRecord) (current []PersonalRecord) {
+	type key struct {
+		activity int
+		kind     string
+	}
+	index := map[key]int{}
+	for _, record := range records {
+		k := key{record.Activity.Id, record.Kind}
+		if i, ok := index[k]; ok {
+			current[i] = record
+			continue
+		}
+		index[k] = len(current)
+		current = append(current, record)
+	}
+
+	order := map[string]int{}
+	for i, kind := range recordKinds() {
+		order[kind] = i
+	}
+	sort.SliceStable(current, func(i, j int) bool {
+		if current[i].Activity.Name != current[j].Activity.Name {
+			return current[i].Activity.Name < current[j].Activity.Name
+		}
+		return order[current[i].Kind] < order[current[j].Kind]
+	})
+	return
+}
+
+// A set Fitocracy flagged as a PR, or that set a record here, or both
+type PRFlagComparison struct {
+	UserActivityDetail
+	Fitocracy bool
+	Local     []string
+}
+
+// Sets where Fitocracy's is_pr flag or our own records say a PR was set.
+// Volume records belong to a workout rather than a set, so aren't compared.
+func ComparePRFlags(details []UserActivityDetail, records []PersonalRecord) (compared []PRFlagComparison) {
+	local := map[int][]string{}
+	for _, record := range records {
+		if record.Kind != recordVolume {
+			local[record.UserActivity.Id] = append(local[record.UserActivity.Id], record.Kind)
+		}
+	}
+	for _, detail := range details {
+		kinds := local[detail.UserActivity.Id]
+		if detail.IsPR || len(kinds) > 0 {
+			compared = append(compared, PRFlagComparison{detail, detail.IsPR, kinds})
+		}
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalRecords(t *testing.T) {
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 2, 5, 100, "kg"),
+		testSet(3, 2, 2, 3, 110, "kg"),
+		testSet(4, 3, 2, 10, 110, "kg"),
+	}
+	details[2].IsPR = true
+	details[3].IsPR = true
+	records := PersonalRecords(EstimateSets(details, EstimateOneRepMax, "kg"))
+
+	kinds := map[int][]string{}
+	for _, r := range records {
+		kinds[r.UserActivity.Id] = append(kinds[r.UserActivity.Id], r.Kind)
+	}
+	//the second set of 100x5 ties, so only the workout's volume is a record
+	assert.Equal(t, []string{"1rm", "3rm", "5rm", "e1rm"}, kinds[1])
+	assert.Equal(t, []string{"volume"}, kinds[2])
+	assert.Equal(t, []string{"1rm", "3rm", "e1rm"}, kinds[3])
+	assert.Equal(t, []string{"5rm", "10rm", "e1rm", "volume"}, kinds[4])
+
+	current := CurrentRecords(records)
+	assert.Equal(t, "1rm", current[0].Kind)
+	assert.Equal(t, 110.0, current[0].Value)
+	assert.Equal(t, "volume", current[len(current)-1].Kind)
+	assert.Equal(t, 1000.0, current[len(current)-1].Previous)
+
+	compared := ComparePRFlags(details, records)
+	assert.Len(t, compared, 3)
+	assert.True(t, compared[1].Fitocracy)
+	assert.Equal(t, []string{"1rm", "3rm", "e1rm"}, compared[1].Local)
+}
+
+func TestRecordsIncluded(t *testing.T) {
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 2, 2, 5, 90, "kg"),
+		testSet(3, 3, 2, 5, 105, "kg"),
+	}
+	filter := ActivityFilter{Since: time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	//the 90kg set is the first in the range but no record against the whole history
+	records := RecordsIncluded(PersonalRecords(EstimateSets(details, EstimateOneRepMax, "kg")), filter)
+	for _, r := range records {
+		assert.Equal(t, 3, r.UserActivity.Id)
+	}
+	assert.NotEmpty(t, records)
+	assert.Equal(t, 100.0, records[0].Previous)
+
+	//which is what judging the range alone gets wrong
+	ranged := PersonalRecords(EstimateSets(details[1:], EstimateOneRepMax, "kg"))
+	assert.Equal(t, 2, ranged[0].UserActivity.Id)
+}
+
+func TestRecordsIncludedByWorkout(t *testing.T) {
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 2, 2, 5, 90, "kg"),
+		testSet(3, 3, 2, 5, 95, "kg"),
+	}
+	details[0].WorkoutName = "Leg Day"
+	details[1].WorkoutName = "Light Legs"
+	details[2].WorkoutName = "Light Legs"
+
+	//judged against every workout, neither light session sets a rep max
+	records := RecordsIncluded(PersonalRecords(EstimateSets(details, EstimateOneRepMax, "kg")), ActivityFilter{Workout: "light legs"})
+	assert.Empty(t, records)
+
+	records = RecordsIncluded(PersonalRecords(EstimateSets(details, EstimateOneRepMax, "kg")), ActivityFilter{Workout: "1"})
+	assert.NotEmpty(t, records)
+	for _, r := range records {
+		assert.Equal(t, 1, r.UserActivity.Id)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var reportFormats = []string{"table", "csv", "json"}
+
+// Rows of named columns, for the stats commands to print as a table or
+// write as CSV or JSON. Values are strings, ints, float64s, times or nil.
+type Report struct {
+	Columns  []string
+	Rows     [][]interface{}
+	Location *time.Location
+}
+
+func NewReport(location *time.Location, columns ...string) *Report {
+	return &Report{Columns: columns, Location: location}
+}
+
+func (r *Report) AddRow(values ...interface{}) {
+	r.Rows = append(r.Rows, values)
+}
+
+// Register -format on a command's flags
+func addReportFormatFlag(flags *flag.FlagSet) *string {
+	return flags.String("format", "table", "Output format: "+strings.Join(reportFormats, ", "))
+}
+
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "table":
+		return r.writeTable(w)
+	case "csv":
+		return r.writeCSV(w)
+	case "json":
+		return r.writeJSON(w)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(reportFormats, ", "))
+}
+
+// Times are reported as dates in the report's timezone, fractional numbers
+// to a tenth, and nils (values that don't apply) as blanks
+func (r *Report) format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return wallClock(v, r.Location).Format(filterDateFormat)
+	case float64:
+		return strconv.FormatFloat(round(v, 1), 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func (r *Report) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(r.Columns, "\t")))
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = r.format(value)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (r *Report) writeCSV(w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write(r.Columns)
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = r.format(value)
+		}
+		csvWriter.Write(cells)
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// An array with an object per row, keyed by column in column order
+func (r *Report) writeJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range r.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+		for j, value := range row {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			switch v := value.(type) {
+			case time.Time:
+				value = r.format(v)
+			case float64:
+				value = round(v, 1)
+			}
+			key, _ := json.Marshal(r.Columns[j])
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteString(": ")
+			buf.Write(encoded)
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("\n]\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tlianza/fitocracypal/xlsx"
+)
+
+// Writes an Excel workbook with a summary sheet followed by one sheet per
+// strength exercise. Each exercise sheet has a row for every run of identical
+// sets (same day, reps and weight), so a 5x5 at one weight is a single row.
+type XLSXExporter struct {
+	WeightUnits string
+}
+
+type spreadsheetRow struct {
+	date   time.Time
+	sets   int
+	reps   float64
+	weight float64
+}
+
+type spreadsheetExercise struct {
+	name     string
+	rows     []*spreadsheetRow
+	sessions map[string]bool
+	sets     int
+	reps     float64
+	best     float64
+	bestE1RM float64
+}
+
+func (x XLSXExporter) FileExtension() string {
+	return "xlsx"
+}
+
+// Each strength exercise's rows and totals, by name
+func spreadsheetExercises(details []UserActivityDetail, weightUnits string) []*spreadsheetExercise {
+	exercises := map[int]*spreadsheetExercise{}
+	for _, detail := range details {
+		if !detail.IsStrength() {
+			continue
+		}
+		exercise, ok := exercises[detail.Activity.Id]
+		if !ok {
+			exercise = &spreadsheetExercise{name: detail.Activity.Name, sessions: map[string]bool{}}
+			exercises[detail.Activity.Id] = exercise
+		}
+
+		weight := ConvertWeight(detail.Weight, detail.Units, weightUnits)
+		day := detail.PerformedAt.Format("2006-01-02")
+		exercise.sessions[day] = true
+		exercise.sets++
+		exercise.reps += detail.Reps
+		exercise.best = math.Max(exercise.best, weight)
+		exercise.bestE1RM = math.Max(exercise.bestE1RM, EstimateOneRepMax(weight, detail.Reps))
+
+		if n := len(exercise.rows); n > 0 {
+			last := exercise.rows[n-1]
+			if last.date.Format("2006-01-02") == day && last.reps == detail.Reps && last.weight == weight {
+				last.sets++
+				continue
+			}
+		}
+		exercise.rows = append(exercise.rows, &spreadsheetRow{date: detail.PerformedAt, sets: 1, reps: detail.Reps, weight: weight})
+	}
+
+	ordered := []*spreadsheetExercise{}
+	for _, exercise := range exercises {
+		ordered = append(ordered, exercise)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].name < ordered[j].name })
+	return ordered
+}
+
+func (x XLSXExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	ordered := spreadsheetExercises(details, x.WeightUnits)
+
+	workbook := xlsx.NewWorkbook()
+	summary := workbook.AddSheet("Summary")
+	summary.AddRow(
+		xlsx.Header("Exercise"), xlsx.Header("First Performed"), xlsx.Header("Last Performed"), xlsx.Header("Sessions"),
+		xlsx.Header("Sets"), xlsx.Header("Reps"), xlsx.Header("Best Weight ("+x.WeightUnits+")"), xlsx.Header("Best Est. 1RM ("+x.WeightUnits+")"),
+	)
+	for _, exercise := range ordered {
+		summary.AddRow(
+			xlsx.String(exercise.name),
+			xlsx.Date(exercise.rows[0].date),
+			xlsx.Date(exercise.rows[len(exercise.rows)-1].date),
+			xlsx.Number(float64(len(exercise.sessions))),
+			xlsx.Number(float64(exercise.sets)),
+			xlsx.Number(exercise.reps),
+			xlsx.Number(round(exercise.best, 2)),
+			xlsx.Number(round(exercise.bestE1RM, 1)),
+		)
+
+		sheet := workbook.AddSheet(exercise.name)
+		sheet.AddRow(xlsx.Header("Date"), xlsx.Header("Sets"), xlsx.Header("Reps"), xlsx.Header("Weight ("+x.WeightUnits+")"), xlsx.Header("Est. 1RM ("+x.WeightUnits+")"))
+		for _, row := range exercise.rows {
+			sheet.AddRow(
+				xlsx.Date(row.date),
+				xlsx.Number(float64(row.sets)),
+				xlsx.Number(row.reps),
+				xlsx.Number(round(row.weight, 2)),
+				xlsx.Number(round(EstimateOneRepMax(row.weight, row.reps), 1)),
+			)
+		}
+	}
+
+	return workbook.Write(w)
+}
+
+func round(f float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(f*scale) / scale
+}
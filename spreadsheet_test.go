@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpreadsheetExercises(t *testing.T) {
+	run := testSet(5, 2, 518, 0, 0, "")
+	run.Activity.Name = "Running"
+	run.Duration = 1800
+	legacyRun := testSet(6, 2, 518, 1, 45, "min") //cardio synced without a duration
+	legacyRun.Activity.Name = "Running"
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 5, 100, "kg"),
+		testSet(2, 1, 2, 5, 100, "kg"),
+		testSet(3, 1, 2, 3, 110, "kg"),
+		testSet(4, 2, 2, 5, 105, "kg"),
+		run,
+		legacyRun,
+	}
+
+	exercises := spreadsheetExercises(details, "kg")
+	assert.Len(t, exercises, 1)
+	squat := exercises[0]
+	assert.Equal(t, "Barbell Squat", squat.name)
+	assert.Equal(t, 4, squat.sets)
+	assert.Len(t, squat.sessions, 2)
+	assert.Len(t, squat.rows, 3)
+	assert.Equal(t, 2, squat.rows[0].sets)
+	assert.Equal(t, 110.0, squat.best)
+	assert.InDelta(t, EstimateOneRepMax(105, 5), squat.bestE1RM, 0.001)
+}
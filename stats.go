@@ -0,0 +1,591 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/viper"
+)
+
+// Handles "stats <command>", for reports computed from the synced history
+func runStats(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: stats 1rm|prs|volume|frequency|heatmap|plateaus|scores|points|compare")
+	}
+	switch args[0] {
+	case "1rm":
+		return runStatsOneRepMax(db, username, args[1:])
+	case "prs":
+		return runStatsPersonalRecords(db, username, args[1:])
+	case "volume":
+		return runStatsVolume(db, username, exerciseMapper, args[1:])
+	case "frequency":
+		return runStatsFrequency(db, username, args[1:])
+	case "heatmap":
+		return runStatsHeatmap(db, username, args[1:])
+	case "plateaus":
+		return runStatsPlateaus(db, username, exerciseMapper, args[1:])
+	case "scores":
+		return runStatsScores(db, username, args[1:])
+	case "points":
+		return runStatsPoints(db, username, args[1:])
+	case "compare":
+		return runStatsCompare(db, username, exerciseMapper, args[1:])
+	}
+	return fmt.Errorf("unknown stats command %s", args[0])
+}
+
+// The user's sets matching the filter
+func statsDetails(db *sqlx.DB, username string, filter ActivityFilter) ([]UserActivityDetail, error) {
+	err, user := GetUserByUsername(db, username)
+	if nil != err {
+		return nil, err
+	}
+	err, details := GetUserActivityDetails(db, user.Id, filter)
+	return details, err
+}
+
+// Register -formula on a command's flags, defaulting to one_rep_max_formula
+// from config.toml. Call the returned function after parsing to look it up.
+func addOneRepMaxFormulaFlag(flags *flag.FlagSet) func() (OneRepMaxFormula, error) {
+	name := flags.String("formula", viper.GetString("one_rep_max_formula"), "Estimated 1RM formula: "+strings.Join(oneRepMaxFormulaNames(), ", "))
+	return func() (OneRepMaxFormula, error) {
+		formula, ok := oneRepMaxFormulas[strings.ToLower(*name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown 1RM formula %q", *name)
+		}
+		return formula, nil
+	}
+}
+
+// Handles "stats 1rm [-by=set|workout|progression]": estimated one rep
+// maxes for every set, the best set of each exercise in each workout, or the
+// sets that raised each exercise's all-time best
+func runStatsOneRepMax(db *sqlx.DB, username string, args []string) error {
+	statsFlags := flag.NewFlagSet("stats 1rm", flag.ExitOnError)
+	by := statsFlags.String("by", "workout", "Rows to report: set, workout or progression")
+	format := addReportFormatFlag(statsFlags)
+	formulaFlag := addOneRepMaxFormulaFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	formula, err := formulaFlag()
+	if err != nil {
+		return err
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+
+	units := viper.GetString("weight_units")
+	estimates := EstimateSets(details, formula, units)
+	var report *Report
+	switch *by {
+	case "set":
+		report = NewReport(configuredLocation(), "date", "exercise_id", "exercise", "workout_id", "reps", "weight", "units", "e1rm")
+		for _, e := range estimates {
+			report.AddRow(e.PerformedAt, e.Activity.Id, e.Activity.Name, e.FitocracyGroupId, e.Reps, e.Weight, units, e.OneRepMax)
+		}
+	case "workout":
+		report = NewReport(configuredLocation(), "date", "exercise_id", "exercise", "workout_id", "workout", "reps", "weight", "units", "e1rm")
+		for _, e := range BestSetPerWorkout(estimates) {
+			report.AddRow(e.PerformedAt, e.Activity.Id, e.Activity.Name, e.FitocracyGroupId, e.WorkoutName, e.Reps, e.Weight, units, e.OneRepMax)
+		}
+	case "progression":
+		report = NewReport(configuredLocation(), "date", "exercise_id", "exercise", "reps", "weight", "units", "e1rm", "previous_e1rm", "gain")
+		for _, r := range OneRepMaxProgression(estimates) {
+			report.AddRow(r.PerformedAt, r.Activity.Id, r.Activity.Name, r.Reps, r.Weight, units, r.OneRepMax, r.Previous, r.OneRepMax-r.Previous)
+		}
+	default:
+		statsFlags.PrintDefaults()
+		return fmt.Errorf("unknown -by %q", *by)
+	}
+	return report.Write(os.Stdout, *format)
+}
+
+// Handles "stats prs [-by=timeline|current|compare]": every personal record
+// in the order set, each exercise's standing records, or the sets where our
+// records and Fitocracy's is_pr flags say a PR was set
+func runStatsPersonalRecords(db *sqlx.DB, username string, args []string) error {
+	statsFlags := flag.NewFlagSet("stats prs", flag.ExitOnError)
+	by := statsFlags.String("by", "timeline", "Rows to report: timeline, current or compare")
+	format := addReportFormatFlag(statsFlags)
+	formulaFlag := addOneRepMaxFormulaFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	formula, err := formulaFlag()
+	if err != nil {
+		return err
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+	history, err := statsDetails(db, username, filter.History())
+	if err != nil {
+		return err
+	}
+
+	units := viper.GetString("weight_units")
+	all := PersonalRecords(EstimateSets(history, formula, units))
+	records := RecordsIncluded(all, filter)
+	var report *Report
+	switch *by {
+	case "timeline", "current":
+		if *by == "current" {
+			//the records standing at --until, if they were set after --since
+			records = RecordsIncluded(CurrentRecords(RecordsIncluded(all, ActivityFilter{Until: filter.Until})), filter)
+		}
+		report = NewReport(configuredLocation(), "date", "exercise_id", "exercise", "record", "value", "previous", "units", "set", "workout_id")
+		for _, r := range records {
+			report.AddRow(r.PerformedAt, r.Activity.Id, r.Activity.Name, r.Kind, r.Value, r.Previous, units, r.Describe(), r.FitocracyGroupId)
+		}
+	case "compare":
+		report = NewReport(configuredLocation(), "date", "exercise_id", "exercise", "set", "fitocracy_pr", "local_records")
+		agree, fitocracyOnly, localOnly := 0, 0, 0
+		for _, c := range ComparePRFlags(details, records) {
+			report.AddRow(c.PerformedAt, c.Activity.Id, c.Activity.Name, c.Describe(), c.Fitocracy, strings.Join(c.Local, " "))
+			switch {
+			case c.Fitocracy && len(c.Local) > 0:
+				agree++
+			case c.Fitocracy:
+				fitocracyOnly++
+			default:
+				localOnly++
+			}
+		}
+		log.Printf("%d PR sets agree, %d only flagged by Fitocracy, %d only found locally\n", agree, fitocracyOnly, localOnly)
+	default:
+		statsFlags.PrintDefaults()
+		return fmt.Errorf("unknown -by %q", *by)
+	}
+	return report.Write(os.Stdout, *format)
+}
+
+// Handles "stats volume [-period=week|month] [-by=exercise|workout|muscle|total]":
+// sets, reps and tonnage per period
+func runStatsVolume(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	statsFlags := flag.NewFlagSet("stats volume", flag.ExitOnError)
+	period := statsFlags.String("period", "week", "Period to total: "+strings.Join(volumePeriods, ", "))
+	by := statsFlags.String("by", "exercise", "Totals for each: "+strings.Join(volumeGroupings, ", "))
+	format := addReportFormatFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	if !isOneOf(*period, volumePeriods) {
+		statsFlags.PrintDefaults()
+		return fmt.Errorf("unknown -period %q", *period)
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+
+	options := VolumeOptions{
+		Period:     *period,
+		By:         *by,
+		Units:      viper.GetString("weight_units"),
+		BodyWeight: viper.GetFloat64("body_weight"),
+		Location:   configuredLocation(),
+	}
+	volume, err := Volume(details, exerciseMapper, options)
+	if err != nil {
+		statsFlags.PrintDefaults()
+		return err
+	}
+
+	var report *Report
+	switch *by {
+	case "exercise":
+		report = NewReport(options.Location, *period, "exercise_id", "exercise", "sets", "reps", "tonnage", "units")
+	case "total":
+		report = NewReport(options.Location, *period, "sets", "reps", "tonnage", "units")
+	default:
+		report = NewReport(options.Location, *period, *by, "sets", "reps", "tonnage", "units")
+	}
+	for _, row := range volume {
+		values := []interface{}{row.Period}
+		switch *by {
+		case "exercise":
+			values = append(values, row.Id, row.Name)
+		case "workout", "muscle":
+			values = append(values, row.Name)
+		}
+		report.AddRow(append(values, row.Sets, row.Reps, row.Tonnage, options.Units)...)
+	}
+	return report.Write(os.Stdout, *format)
+}
+
+// Handles "stats frequency [-by=summary|week|streaks|gaps]": how consistently
+// the user has trained
+func runStatsFrequency(db *sqlx.DB, username string, args []string) error {
+	statsFlags := flag.NewFlagSet("stats frequency", flag.ExitOnError)
+	by := statsFlags.String("by", "summary", "Rows to report: summary, week, streaks or gaps")
+	minDays := statsFlags.Int("min-days", 1, "Training days a week needs to continue a weekly streak")
+	minGap := statsFlags.Int("min-gap", 7, "Days without training to report as a gap")
+	format := addReportFormatFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+
+	location := configuredLocation()
+	days := TrainingDays(details, location)
+	weeks := TrainingWeeks(days)
+	var report *Report
+	switch *by {
+	case "summary":
+		report = NewReport(location, "stat", "value", "start", "end")
+		if len(days) == 0 {
+			break
+		}
+		report.AddRow("training_days", len(days), days[0].Date, days[len(days)-1].Date)
+		report.AddRow("days_per_week", float64(len(days))/float64(len(weeks)), weeks[0].Start, weeks[len(weeks)-1].Start)
+		for _, stat := range []struct {
+			name  string
+			spans []Span
+		}{
+			{"longest_daily_streak", DailyStreaks(days)},
+			{"longest_weekly_streak", WeeklyStreaks(weeks, *minDays)},
+			{"longest_gap", Gaps(days, 1)},
+		} {
+			if len(stat.spans) > 0 {
+				report.AddRow(stat.name, stat.spans[0].Length, stat.spans[0].Start, stat.spans[0].End)
+			}
+		}
+	case "week":
+		report = NewReport(location, "week", "training_days", "sets")
+		for _, week := range weeks {
+			report.AddRow(week.Start, week.Days, week.Sets)
+		}
+	case "streaks":
+		report = NewReport(location, "start", "end", "weeks")
+		for _, streak := range WeeklyStreaks(weeks, *minDays) {
+			report.AddRow(streak.Start, streak.End, streak.Length)
+		}
+	case "gaps":
+		report = NewReport(location, "start", "end", "days")
+		for _, gap := range Gaps(days, *minGap) {
+			report.AddRow(gap.Start, gap.End, gap.Length)
+		}
+	default:
+		statsFlags.PrintDefaults()
+		return fmt.Errorf("unknown -by %q", *by)
+	}
+	return report.Write(os.Stdout, *format)
+}
+
+// Handles "stats heatmap [-out=heatmap.svg]": a calendar of training days
+func runStatsHeatmap(db *sqlx.DB, username string, args []string) error {
+	statsFlags := flag.NewFlagSet("stats heatmap", flag.ExitOnError)
+	out := statsFlags.String("out", "heatmap.svg", "File to write")
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	err = WriteHeatmap(f, TrainingDays(details, configuredLocation()))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Handles "stats plateaus [-weeks=8] [-drop=10]": exercises whose estimated
+// 1RM or volume has stalled or dropped in the last few weeks of training
+func runStatsPlateaus(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	statsFlags := flag.NewFlagSet("stats plateaus", flag.ExitOnError)
+	weeks := statsFlags.Int("weeks", viper.GetInt("plateau_weeks"), "Length of the window judged, in weeks")
+	drop := statsFlags.Float64("drop", viper.GetFloat64("regression_drop"), "Percentage below the window before that counts as a regression")
+	minSessions := statsFlags.Int("min-sessions", 3, "Sessions an exercise needs in the window to be judged")
+	all := statsFlags.Bool("all", false, "Include improving exercises too")
+	metrics := &stringList{}
+	statsFlags.Var(metrics, "metric", "Metric to judge, e1rm or volume (repeatable; defaults to both)")
+	format := addReportFormatFlag(statsFlags)
+	formulaFlag := addOneRepMaxFormulaFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	formula, err := formulaFlag()
+	if err != nil {
+		return err
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+	if len(*metrics) == 0 {
+		*metrics = []string{"e1rm", "volume"}
+	}
+
+	//the window ends with the last training day, since histories are often old
+	options := PlateauOptions{
+		Window:      time.Duration(*weeks) * 7 * 24 * time.Hour,
+		Drop:        *drop / 100,
+		MinSessions: *minSessions,
+	}
+	if len(details) > 0 {
+		options.AsOf = details[len(details)-1].PerformedAt
+	}
+
+	units := viper.GetString("weight_units")
+	location := configuredLocation()
+	report := NewReport(location, "exercise_id", "exercise", "metric", "status", "sessions", "window_best", "prior_best",
+		"change_from_best", "change_from_previous_window", "last_improved", "last_performed")
+	for _, metric := range *metrics {
+		if metric != "e1rm" && metric != "volume" {
+			return fmt.Errorf("unknown -metric %q", metric)
+		}
+		series, err := ProgressSeries(details, exerciseMapper, metric, formula, units, viper.GetFloat64("body_weight"))
+		if err != nil {
+			return err
+		}
+		for _, t := range Trends(series, options) {
+			if t.Status == trendImproving && !*all {
+				continue
+			}
+			report.AddRow(t.Id, t.Name, metric, t.Status, t.Sessions, t.WindowBest, t.PriorBest,
+				t.ChangeFromBest(), t.ChangeFromPreviousWindow(), t.LastImproved, t.LastPerformed)
+		}
+	}
+	return report.Write(os.Stdout, *format)
+}
+
+// Handles "stats scores [-e1rm]": Wilks, DOTS and IPF GL points for the
+// squat, bench and deadlift total each day one of their records improves
+func runStatsScores(db *sqlx.DB, username string, args []string) error {
+	statsFlags := flag.NewFlagSet("stats scores", flag.ExitOnError)
+	estimated := statsFlags.Bool("e1rm", false, "Total estimated 1RMs instead of heaviest singles")
+	sex := statsFlags.String("sex", viper.GetString("sex"), "Which coefficients to score with: male or female")
+	format := addReportFormatFlag(statsFlags)
+	formulaFlag := addOneRepMaxFormulaFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	if err := checkSex(*sex); err != nil {
+		return err
+	}
+	formula, err := formulaFlag()
+	if err != nil {
+		return err
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	lifts := PowerliftingLifts{
+		Squat:    viper.GetInt("powerlifting.squat"),
+		Bench:    viper.GetInt("powerlifting.bench"),
+		Deadlift: viper.GetInt("powerlifting.deadlift"),
+	}
+	if len(filter.Exercises) > 0 || filter.Workout != "" {
+		return fmt.Errorf("stats scores always totals the powerlifting lifts, not --exercise or --workout")
+	}
+	//bests carry over from before --since, so score the whole history of the three lifts
+	history, err := statsDetails(db, username, ActivityFilter{
+		Exercises: []string{strconv.Itoa(lifts.Squat), strconv.Itoa(lifts.Bench), strconv.Itoa(lifts.Deadlift)},
+	})
+	if err != nil {
+		return err
+	}
+	bodyWeights, err := userBodyWeightSeries(db, username)
+	if err != nil {
+		return err
+	}
+
+	kind := repMaxRecords[0].kind
+	if *estimated {
+		kind = recordEstimatedOneRepMax
+	}
+	units := viper.GetString("weight_units")
+	location := configuredLocation()
+	records := PersonalRecords(EstimateSets(history, formula, units))
+	report := NewReport(location, "date", "squat", "bench", "deadlift", "total", "body_weight", "units", "wilks", "dots", "ipf_gl")
+	for _, s := range StrengthScores(records, kind, lifts, bodyWeights, viper.GetFloat64("body_weight"), units, *sex, location) {
+		if !filter.Includes(s.Time) {
+			continue
+		}
+		report.AddRow(s.Time, s.Squat, s.Bench, s.Deadlift, s.Total, s.BodyWeight, units, s.Wilks, s.DOTS, s.IPFGL)
+	}
+	return report.Write(os.Stdout, *format)
+}
+
+// Handles "stats points [-by=workout|week|month|exercise]": the points
+// Fitocracy gave each set next to our estimate, with the score using the
+// estimate for sets Fitocracy never scored
+func runStatsPoints(db *sqlx.DB, username string, args []string) error {
+	statsFlags := flag.NewFlagSet("stats points", flag.ExitOnError)
+	by := statsFlags.String("by", "workout", "Rows to report: "+strings.Join(pointsGroupings, ", "))
+	format := addReportFormatFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	//calibrate from the whole history, not just the sets being reported
+	history, err := statsDetails(db, username, ActivityFilter{})
+	if err != nil {
+		return err
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+
+	location := configuredLocation()
+	model := CalibratePoints(history)
+	points, err := Points(details, model, *by, location)
+	if err != nil {
+		statsFlags.PrintDefaults()
+		return err
+	}
+	if meanError, sets := PointsEstimateError(details, model); sets > 0 {
+		log.Printf("Estimates are off by %.1f%% on average across %d scored sets\n", meanError*100, sets)
+	}
+
+	var report *Report
+	switch *by {
+	case "workout":
+		report = NewReport(location, "date", "workout_id", "workout", "sets", "recorded", "estimated", "score")
+	case "exercise":
+		report = NewReport(location, "first_performed", "exercise_id", "exercise", "sets", "recorded", "estimated", "score")
+	default:
+		report = NewReport(location, *by, "sets", "recorded", "estimated", "score")
+	}
+	for _, row := range points {
+		values := []interface{}{row.Time}
+		if *by == "workout" || *by == "exercise" {
+			values = append(values, row.Id, row.Name)
+		}
+		report.AddRow(append(values, row.Sets, row.Recorded, row.Estimated, row.Score)...)
+	}
+	return report.Write(os.Stdout, *format)
+}
+
+// Handles "stats compare [-period=week|month|quarter|year]": sessions, sets,
+// tonnage, top sets and PRs per exercise in one date range against another,
+// either a calendar period and the one before or -current and -previous
+func runStatsCompare(db *sqlx.DB, username string, exerciseMapper *ExerciseMapper, args []string) error {
+	statsFlags := flag.NewFlagSet("stats compare", flag.ExitOnError)
+	period := statsFlags.String("period", "year", "Compare the "+strings.Join(comparePeriods, ", ")+" containing -as-of with the one before")
+	asOf := statsFlags.String("as-of", "", "Date in the period to compare (YYYY-MM-DD); defaults to the last day trained")
+	current := statsFlags.String("current", "", "Range to compare instead of a period (YYYY-MM-DD..YYYY-MM-DD)")
+	previous := statsFlags.String("previous", "", "Range to compare -current against (YYYY-MM-DD..YYYY-MM-DD)")
+	format := addReportFormatFlag(statsFlags)
+	formulaFlag := addOneRepMaxFormulaFlag(statsFlags)
+	filterFlags := addFilterFlags(statsFlags)
+	statsFlags.Parse(args)
+
+	formula, err := formulaFlag()
+	if err != nil {
+		return err
+	}
+	filter, err := filterFlags()
+	if err != nil {
+		return err
+	}
+	if !filter.Since.IsZero() || !filter.Until.IsZero() {
+		return fmt.Errorf("stats compare takes its dates from -period or -current and -previous, not --since and --until")
+	}
+	details, err := statsDetails(db, username, filter)
+	if err != nil {
+		return err
+	}
+
+	location := configuredLocation()
+	options := CompareOptions{
+		Formula:    formula,
+		Units:      viper.GetString("weight_units"),
+		BodyWeight: viper.GetFloat64("body_weight"),
+	}
+	switch {
+	case *current != "" && *previous != "":
+		if options.Current, err = ParseDateRange(*current, location); err != nil {
+			return err
+		}
+		if options.Previous, err = ParseDateRange(*previous, location); err != nil {
+			return err
+		}
+	case *current != "" || *previous != "":
+		return fmt.Errorf("-current and -previous must be given together")
+	default:
+		day := time.Now().In(location)
+		if *asOf != "" {
+			if day, err = time.ParseInLocation(filterDateFormat, *asOf, location); err != nil {
+				return fmt.Errorf("invalid -as-of date: %s", err)
+			}
+		} else if len(details) > 0 {
+			day = details[len(details)-1].PerformedAt
+		}
+		if options.Current, options.Previous, err = CalendarPeriods(*period, day, location); err != nil {
+			return err
+		}
+	}
+	log.Printf("Comparing %s to %s with %s to %s\n",
+		options.Current.Start.Format(filterDateFormat), options.Current.End.AddDate(0, 0, -1).Format(filterDateFormat),
+		options.Previous.Start.Format(filterDateFormat), options.Previous.End.AddDate(0, 0, -1).Format(filterDateFormat))
+
+	report := NewReport(location, "exercise_id", "exercise", "metric", "previous", "current", "change", "change_pct")
+	for _, c := range ComparePeriodStats(details, exerciseMapper, options) {
+		var id interface{} = c.Id
+		if c.Id == 0 {
+			id = nil
+		}
+		for _, metric := range compareMetrics {
+			before, after := metric.value(c.Previous), metric.value(c.Current)
+			if before == 0 && after == 0 {
+				continue
+			}
+			var pct interface{}
+			if change, ok := percentChange(before, after); ok {
+				pct = change
+			}
+			report.AddRow(id, c.Name, metric.name, before, after, after-before, pct)
+		}
+	}
+	return report.Write(os.Stdout, *format)
+}
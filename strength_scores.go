@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Powerlifting scores compare totals across body weights. Every formula
+// works in kilograms; body weights are clamped to the range each formula
+// was fitted over.
+type scoreCoefficients struct {
+	coefficients []float64
+	minWeight    float64
+	maxWeight    float64
+}
+
+// Wilks (1994), as a polynomial a + b*bw + c*bw^2 + ... + f*bw^5
+var wilksCoefficients = map[string]scoreCoefficients{
+	"male":   {[]float64{-216.0475144, 16.2606339, -0.002388645, -0.00113732, 7.01863e-06, -1.291e-08}, 40, 201.9},
+	"female": {[]float64{594.31747775582, -27.23842536447, 0.82112226871, -0.00930733913, 4.731582e-05, -9.054e-08}, 26.51, 154.53},
+}
+
+// DOTS, as a polynomial e + d*bw + c*bw^2 + b*bw^3 + a*bw^4
+var dotsCoefficients = map[string]scoreCoefficients{
+	"male":   {[]float64{-307.75076, 24.0900756, -0.1918759221, 0.0007391293, -0.000001093}, 40, 210},
+	"female": {[]float64{-57.96288, 13.6175032, -0.1126655495, 0.0005158568, -0.0000010706}, 40, 150},
+}
+
+// IPF GL points for raw classic powerlifting: A - B*e^(-C*bw)
+var ipfGLCoefficients = map[string][3]float64{
+	"male":   {1199.72839, 1025.18162, 0.00921},
+	"female": {610.32796, 1045.59282, 0.03048},
+}
+
+func checkSex(sex string) error {
+	if _, ok := wilksCoefficients[sex]; !ok {
+		return fmt.Errorf("unknown sex %q, expected male or female", sex)
+	}
+	return nil
+}
+
+func (s scoreCoefficients) polynomial(bodyWeightKg float64) (sum float64) {
+	x := math.Max(s.minWeight, math.Min(s.maxWeight, bodyWeightKg))
+	for i := len(s.coefficients) - 1; i >= 0; i-- {
+		sum = sum*x + s.coefficients[i]
+	}
+	return
+}
+
+func WilksScore(totalKg float64, bodyWeightKg float64, sex string) float64 {
+	return totalKg * 500 / wilksCoefficients[sex].polynomial(bodyWeightKg)
+}
+
+func DOTSScore(totalKg float64, bodyWeightKg float64, sex string) float64 {
+	return totalKg * 500 / dotsCoefficients[sex].polynomial(bodyWeightKg)
+}
+
+func IPFGLScore(totalKg float64, bodyWeightKg float64, sex string) float64 {
+	c := ipfGLCoefficients[sex]
+	return totalKg * 100 / (c[0] - c[1]*math.Exp(-c[2]*bodyWeightKg))
+}
+
+// The squat, bench and deadlift bests standing at a point in time, the body
+// weight then, and the scores for their total
+type StrengthScore struct {
+	Time       time.Time
+	Squat      float64
+	Bench      float64
+	Deadlift   float64
+	Total      float64
+	BodyWeight float64
+	Wilks      float64
+	DOTS       float64
+	IPFGL      float64
+}
+
+// The lifts a total is made of, by Fitocracy activity id
+type PowerliftingLifts struct {
+	Squat, Bench, Deadlift int
+}
+
+// Score the total on each day one of the lifts' records improves, once all
+// three have been performed. Records and body weights are in units.
+func StrengthScores(records []PersonalRecord, kind string, lifts PowerliftingLifts, bodyWeights []BodyWeightPoint, fallbackBodyWeight float64, units string, sex string, location *time.Location) (scores []StrengthScore) {
+	records = append([]PersonalRecord{}, records...)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].PerformedAt.Before(records[j].PerformedAt) })
+
+	best := map[int]float64{}
+	for _, r := range records {
+		if r.Kind != kind || (r.Activity.Id != lifts.Squat && r.Activity.Id != lifts.Bench && r.Activity.Id != lifts.Deadlift) {
+			continue
+		}
+		best[r.Activity.Id] = r.Value
+		if len(best) < 3 {
+			continue
+		}
+		score := StrengthScore{
+			Time:       r.PerformedAt,
+			Squat:      best[lifts.Squat],
+			Bench:      best[lifts.Bench],
+			Deadlift:   best[lifts.Deadlift],
+			BodyWeight: BodyWeightAt(bodyWeights, r.PerformedAt, fallbackBodyWeight),
+		}
+		score.Total = score.Squat + score.Bench + score.Deadlift
+		if score.BodyWeight > 0 {
+			totalKg := ConvertWeight(score.Total, units, "kg")
+			bodyWeightKg := ConvertWeight(score.BodyWeight, units, "kg")
+			score.Wilks = WilksScore(totalKg, bodyWeightKg, sex)
+			score.DOTS = DOTSScore(totalKg, bodyWeightKg, sex)
+			score.IPFGL = IPFGLScore(totalKg, bodyWeightKg, sex)
+		}
+		// A day's later records replace its earlier score
+		if n := len(scores); n > 0 && dayOf(scores[n-1].Time, location).Equal(dayOf(r.PerformedAt, location)) {
+			scores[n-1] = score
+		} else {
+			scores = append(scores, score)
+		}
+	}
+	return
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreFormulas(t *testing.T) {
+	assert.InDelta(t, 426.0, WilksScore(700, 100, "male"), 0.1)
+	assert.InDelta(t, 88.4, IPFGLScore(700, 100, "male"), 0.1)
+	//body weights outside the fitted range score as its nearest end
+	assert.Equal(t, WilksScore(700, 201.9, "male"), WilksScore(700, 250, "male"))
+	assert.Equal(t, DOTSScore(300, 40, "female"), DOTSScore(300, 30, "female"))
+	assert.Error(t, checkSex("other"))
+}
+
+func TestStrengthScores(t *testing.T) {
+	details := []UserActivityDetail{
+		testSet(1, 1, 2, 1, 140, "kg"),
+		testSet(2, 1, 1, 1, 100, "kg"),
+		testSet(3, 2, 3, 1, 180, "kg"),
+		testSet(4, 3, 2, 1, 150, "kg"),
+		testSet(5, 3, 3, 1, 190, "kg"),
+		testSet(6, 4, 1, 1, 95, "kg"),
+	}
+	records := PersonalRecords(EstimateSets(details, EstimateOneRepMax, "kg"))
+	weights, err := ParseBodyWeightCSV(strings.NewReader("date,weight,units\n2016-01-01,200,lbs\n2016-01-03,92\n"), "kg", time.UTC)
+	assert.NoError(t, err)
+	bodyWeights := BodyWeightSeries(weights, nil, "kg")
+
+	scores := StrengthScores(records, "1rm", PowerliftingLifts{2, 1, 3}, bodyWeights, 0, "kg", "male", time.UTC)
+	//no total until the deadlift, then one score for both records on day 3
+	assert.Len(t, scores, 2)
+	assert.Equal(t, 420.0, scores[0].Total)
+	assert.InDelta(t, 90.7, scores[0].BodyWeight, 0.05)
+	assert.Equal(t, 440.0, scores[1].Total)
+	assert.Equal(t, 92.0, scores[1].BodyWeight)
+	assert.True(t, scores[1].Wilks > scores[0].Wilks)
+	assert.True(t, scores[1].DOTS > 0 && scores[1].IPFGL > 0)
+}
+
+func TestParseBodyWeightCSV(t *testing.T) {
+	_, err := ParseBodyWeightCSV(strings.NewReader("date,weight\n2016-01-01,heavy\n"), "kg", time.UTC)
+	assert.Error(t, err)
+	_, err = ParseBodyWeightCSV(strings.NewReader("day,weight\n"), "kg", time.UTC)
+	assert.Error(t, err)
+}
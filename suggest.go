@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An exercise from another app's catalog, loaded from a local JSON or CSV file
+type CatalogEntry struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// Load a catalog of exercises. JSON files hold an array of objects with id,
+// name and (optionally) category; CSV files need a header row naming the
+// same columns. Name-only catalogs (Strong, JEFIT) can leave out the id.
+func LoadCatalog(filename string) (catalog []CatalogEntry, err error) {
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(data, &catalog)
+		return catalog, err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty catalog %s", filename)
+	}
+
+	columns := map[string]int{}
+	for i, column := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	nameColumn, ok := columns["name"]
+	if !ok {
+		return nil, fmt.Errorf("catalog %s has no name column", filename)
+	}
+	for _, record := range records[1:] {
+		entry := CatalogEntry{Name: record[nameColumn]}
+		if i, ok := columns["id"]; ok && i < len(record) {
+			entry.Id, _ = strconv.Atoi(strings.TrimSpace(record[i]))
+		}
+		if i, ok := columns["category"]; ok && i < len(record) {
+			entry.Category = record[i]
+		}
+		catalog = append(catalog, entry)
+	}
+	return catalog, nil
+}
+
+// Words that describe the equipment an exercise uses. Two names that both
+// mention equipment but disagree on it are very unlikely to be the same lift.
+var equipmentWords = map[string]bool{
+	"barbell": true, "dumbbell": true, "cable": true, "machine": true, "kettlebell": true,
+	"smith": true, "band": true, "bodyweight": true, "ezbar": true,
+}
+
+// Spellings and abbreviations that mean the same thing
+var synonyms = map[string]string{
+	"db":         "dumbbell",
+	"dbs":        "dumbbell",
+	"dumbell":    "dumbbell",
+	"bb":         "barbell",
+	"kb":         "kettlebell",
+	"pulley":     "cable",
+	"fb":         "ball",
+	"pb":         "ball",
+	"inclined":   "incline",
+	"declined":   "decline",
+	"narrow":     "close",
+	"butterfly":  "fly",
+	"ez":         "ezbar",
+	"bw":         "bodyweight",
+	"flye":       "fly",
+	"situp":      "sit up",
+	"pullup":     "pull up",
+	"pushup":     "push up",
+	"chinup":     "chin up",
+	"bicep":      "biceps",
+	"tricep":     "triceps",
+	"ohp":        "overhead press",
+	"military":   "overhead",
+	"rdl":        "romanian deadlift",
+	"pulldown":   "pull down",
+	"pushdown":   "push down",
+	"crunches":   "crunch",
+	"extensions": "extension",
+}
+
+var stopWords = map[string]bool{"a": true, "the": true, "with": true, "on": true, "version": true, "and": true, "of": true}
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Lowercase a name and break it into comparable tokens: punctuation dropped,
+// synonyms expanded, simple plurals trimmed and "body weight" joined up
+func NormalizeExerciseName(name string) []string {
+	name = strings.ToLower(name)
+	name = strings.Replace(name, "body weight", "bodyweight", -1)
+	name = strings.Replace(name, "ez bar", "ezbar", -1)
+
+	tokens := []string{}
+	for _, word := range nonWord.Split(name, -1) {
+		if word == "" || stopWords[word] {
+			continue
+		}
+		if synonym, ok := synonyms[word]; ok {
+			tokens = append(tokens, strings.Fields(synonym)...)
+			continue
+		}
+		if len(word) > 2 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "ps") {
+			word = strings.TrimSuffix(word, "s")
+			if synonym, ok := synonyms[word]; ok {
+				word = synonym
+			}
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+func tokenSet(tokens []string) map[string]bool {
+	set := map[string]bool{}
+	for _, token := range tokens {
+		set[token] = true
+	}
+	return set
+}
+
+func trigrams(tokens []string) map[string]bool {
+	grams := map[string]bool{}
+	for _, token := range tokens {
+		padded := " " + token + " "
+		for i := 0; i+3 <= len(padded); i++ {
+			grams[padded[i:i+3]] = true
+		}
+	}
+	return grams
+}
+
+func overlap(a map[string]bool, b map[string]bool) (shared int) {
+	for key := range a {
+		if b[key] {
+			shared++
+		}
+	}
+	return
+}
+
+// How alike two exercise names are, from 0 to 1. Mostly the share of tokens
+// the names have in common, with character trigrams to credit near misses,
+// halved when the names disagree about equipment.
+func ExerciseNameSimilarity(a string, b string) float64 {
+	aTokens, bTokens := NormalizeExerciseName(a), NormalizeExerciseName(b)
+	if len(aTokens) == 0 || len(bTokens) == 0 {
+		return 0
+	}
+	aSet, bSet := tokenSet(aTokens), tokenSet(bTokens)
+	tokenScore := 2 * float64(overlap(aSet, bSet)) / float64(len(aSet)+len(bSet))
+
+	aGrams, bGrams := trigrams(aTokens), trigrams(bTokens)
+	gramScore := float64(overlap(aGrams, bGrams)) / float64(len(aGrams)+len(bGrams)-overlap(aGrams, bGrams))
+
+	score := 0.7*tokenScore + 0.3*gramScore
+
+	aEquipment, bEquipment := map[string]bool{}, map[string]bool{}
+	for token := range aSet {
+		if equipmentWords[token] {
+			aEquipment[token] = true
+		}
+	}
+	for token := range bSet {
+		if equipmentWords[token] {
+			bEquipment[token] = true
+		}
+	}
+	if len(aEquipment) > 0 && len(bEquipment) > 0 && overlap(aEquipment, bEquipment) == 0 {
+		score /= 2
+	}
+	return score
+}
+
+type Suggestion struct {
+	Entry CatalogEntry
+	Score float64
+}
+
+// The best catalog matches for an exercise name, highest score first
+func SuggestMappings(name string, catalog []CatalogEntry, limit int) []Suggestion {
+	suggestions := []Suggestion{}
+	for _, entry := range catalog {
+		if score := ExerciseNameSimilarity(name, entry.Name); score > 0 {
+			suggestions = append(suggestions, Suggestion{entry, score})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Score > suggestions[j].Score })
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// The exercise_mappings.toml keys a catalog entry fills in for each target
+// that can be suggested from a catalog. Keys with an empty value are removed,
+// which is how an old id goes when the catalog doesn't have one.
+var suggestionFields = map[string]func(CatalogEntry) [][2]string{
+	"virtuagym": func(c CatalogEntry) [][2]string {
+		return withCatalogId("virtuagym_id", c, [2]string{"virtuagym_name", strconv.Quote(c.Name)})
+	},
+	"mfp": func(c CatalogEntry) [][2]string {
+		return withCatalogId("mfp_id", c, [2]string{"mfp_name", strconv.Quote(c.Name)})
+	},
+	"fitnotes": func(c CatalogEntry) [][2]string {
+		return [][2]string{{"fitnotes_name", strconv.Quote(c.Name)}, {"fitnotes_category", strconv.Quote(c.Category)}}
+	},
+	"jefit": func(c CatalogEntry) [][2]string {
+		return [][2]string{{"jefit_name", strconv.Quote(c.Name)}}
+	},
+	"strong": func(c CatalogEntry) [][2]string {
+		return [][2]string{{"strong_name", strconv.Quote(c.Name)}}
+	},
+}
+
+func withCatalogId(key string, c CatalogEntry, fields ...[2]string) [][2]string {
+	id := ""
+	if c.Id != 0 {
+		id = strconv.Itoa(c.Id)
+	}
+	return append([][2]string{{key, id}}, fields...)
+}
+
+var fitocracyIdLine = regexp.MustCompile(`(?m)^\s*fitocracy_id\s*=\s*(\d+)`)
+
+// Add the chosen catalog entries to a copy of the mapping file's text,
+// leaving everything else (comments, quoting, ordering) as it was. Keys the
+// entry already has are replaced where they are, since TOML doesn't allow
+// a key twice.
+func PatchMappings(toml string, target string, chosen map[int]Suggestion) string {
+	existingLines := map[string]*regexp.Regexp{}
+	for _, field := range suggestionFields[target](CatalogEntry{}) {
+		existingLines[field[0]] = regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(field[0]) + `[ \t]*=.*(\n|$)`)
+	}
+
+	blocks := strings.Split(toml, "[[exercises]]")
+	for i, block := range blocks {
+		match := fitocracyIdLine.FindStringSubmatch(block)
+		if match == nil {
+			continue
+		}
+		fitocracyId, _ := strconv.Atoi(match[1])
+		suggestion, ok := chosen[fitocracyId]
+		if !ok {
+			continue
+		}
+		body := strings.TrimRight(block, "\n")
+		body += fmt.Sprintf("\n# suggested %s mapping (score %.2f), please review", target, suggestion.Score)
+		for _, field := range suggestionFields[target](suggestion.Entry) {
+			line := field[0] + "=" + field[1]
+			existing := existingLines[field[0]]
+			if existing.MatchString(body) {
+				//the comment comes last, so every existing line ends in a newline
+				if field[1] == "" {
+					line = ""
+				} else {
+					line += "\n"
+				}
+				body = existing.ReplaceAllLiteralString(body, line)
+			} else if field[1] != "" {
+				body += "\n" + line
+			}
+		}
+		blocks[i] = body + block[len(strings.TrimRight(block, "\n")):]
+	}
+	return strings.Join(blocks, "[[exercises]]")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeExerciseName(t *testing.T) {
+	assert.Equal(t, []string{"dumbbell", "bench", "press"}, NormalizeExerciseName("DB Bench Press"))
+	assert.Equal(t, []string{"pull", "up"}, NormalizeExerciseName("Pull-Up"))
+	assert.Equal(t, []string{"dumbbell", "fly"}, NormalizeExerciseName("Dumbbell Flyes"))
+	assert.Equal(t, []string{"bodyweight", "squat"}, NormalizeExerciseName("Body Weight Squat"))
+	assert.Equal(t, []string{"triceps", "extension"}, NormalizeExerciseName("Tricep Extensions"))
+}
+
+func TestExerciseNameSimilarity(t *testing.T) {
+	//word order doesn't matter
+	assert.InDelta(t, 1.0, ExerciseNameSimilarity("Barbell Bench Press", "Bench press - Barbell"), 0.001)
+
+	//disagreeing about equipment costs more than leaving it out
+	withoutEquipment := ExerciseNameSimilarity("Barbell Bench Press", "Bench press")
+	otherEquipment := ExerciseNameSimilarity("Barbell Bench Press", "Bench press - DBs")
+	assert.True(t, withoutEquipment > otherEquipment)
+}
+
+func TestSuggestMappings(t *testing.T) {
+	catalog := []CatalogEntry{
+		{Id: 354, Name: "Squat - Barbell"},
+		{Id: 314, Name: "Bench press - Barbell"},
+		{Id: 999, Name: "Bench press inclined - Barbell"},
+		{Id: 315, Name: "Bench press - DBs"},
+	}
+	suggestions := SuggestMappings("Barbell Incline Bench Press", catalog, 2)
+	assert.Len(t, suggestions, 2)
+	assert.Equal(t, 999, suggestions[0].Entry.Id)
+	assert.Equal(t, 314, suggestions[1].Entry.Id)
+}
+
+func TestPatchMappings(t *testing.T) {
+	toml := `[[exercises]]
+fitocracy_name = "Barbell Squat"
+fitocracy_id = 2
+
+[[exercises]]
+fitocracy_name = 'Leg Press'
+fitocracy_id = 177
+`
+	patched := PatchMappings(toml, "virtuagym", map[int]Suggestion{177: {CatalogEntry{Id: 241, Name: "Leg press"}, 0.9}})
+	assert.Equal(t, `[[exercises]]
+fitocracy_name = "Barbell Squat"
+fitocracy_id = 2
+
+[[exercises]]
+fitocracy_name = 'Leg Press'
+fitocracy_id = 177
+# suggested virtuagym mapping (score 0.90), please review
+virtuagym_id=241
+virtuagym_name="Leg press"
+`, patched)
+}
+
+func TestPatchMappingsReplacesExistingKeys(t *testing.T) {
+	//mfp_id = 0 counts as unmapped, so the block gets patched even with a name
+	mappings := `[[exercises]]
+fitocracy_name = "Barbell Squat"
+fitocracy_id = 2
+mfp_name = "Squats"
+mfp_id = 0
+`
+	patched := PatchMappings(mappings, "mfp", map[int]Suggestion{2: {CatalogEntry{Id: 88, Name: "Squat"}, 0.8}})
+	assert.Equal(t, `[[exercises]]
+fitocracy_name = "Barbell Squat"
+fitocracy_id = 2
+mfp_name="Squat"
+mfp_id=88
+# suggested mfp mapping (score 0.80), please review
+`, patched)
+	var file MappingFile
+	_, err := toml.Decode(patched, &file)
+	assert.NoError(t, err)
+
+	//no id in the catalog, so the old id goes rather than pairing with the new name
+	patched = PatchMappings(mappings, "mfp", map[int]Suggestion{2: {CatalogEntry{Name: "Squat"}, 0.8}})
+	assert.Equal(t, `[[exercises]]
+fitocracy_name = "Barbell Squat"
+fitocracy_id = 2
+mfp_name="Squat"
+# suggested mfp mapping (score 0.80), please review
+`, patched)
+
+	stale := `[[exercises]]
+fitocracy_name = "Barbell Squat"
+fitocracy_id = 2
+virtuagym_id = 354
+virtuagym_name = "Squat - Barbell"
+`
+	patched = PatchMappings(stale, "virtuagym", map[int]Suggestion{2: {CatalogEntry{Name: "Back Squat"}, 0.7}})
+	assert.NotContains(t, patched, "354")
+	assert.Contains(t, patched, `virtuagym_name="Back Squat"`)
+	assert.Equal(t, 1, strings.Count(patched, "virtuagym_"))
+}
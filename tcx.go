@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Training Center XML, which Strava, Garmin Connect and most other cardio
+// tools import. Only the handful of elements needed for a manual activity
+// (start, total time and distance) are written.
+type tcxDatabase struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Xmlns      string        `xml:"xmlns,attr"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string `xml:"Sport,attr"`
+	Id    string `xml:"Id"`
+	Lap   tcxLap `xml:"Lap"`
+	Notes string `xml:"Notes,omitempty"`
+}
+
+type tcxLap struct {
+	StartTime        string  `xml:"StartTime,attr"`
+	TotalTimeSeconds float64 `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64 `xml:"DistanceMeters"`
+	Calories         int     `xml:"Calories"`
+	Intensity        string  `xml:"Intensity"`
+	TriggerMethod    string  `xml:"TriggerMethod"`
+}
+
+// Writes every cardio action (anything logged with a time or distance) as its
+// own TCX activity. The sport comes from the exercise's tcx_sport mapping
+// ("Running" or "Biking"), defaulting to "Other".
+type TCXExporter struct{}
+
+func (t TCXExporter) FileExtension() string {
+	return "tcx"
+}
+
+func (t TCXExporter) Export(w io.Writer, details []UserActivityDetail, exerciseMapper *ExerciseMapper) error {
+	database := tcxDatabase{Xmlns: "http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"}
+
+	//activity ids are their start times and have to be unique
+	used := map[time.Time]bool{}
+	for _, detail := range details {
+		if !detail.IsCardio() {
+			continue
+		}
+		start := detail.PerformedAt.UTC()
+		for used[start] {
+			start = start.Add(time.Second)
+		}
+		used[start] = true
+
+		sport := exerciseMapper.ByFitocracyId[detail.Activity.Id].TCXSport
+		if sport == "" {
+			sport = "Other"
+		}
+		database.Activities = append(database.Activities, tcxActivity{
+			Sport: sport,
+			Id:    start.Format(time.RFC3339),
+			Lap: tcxLap{
+				StartTime:        start.Format(time.RFC3339),
+				TotalTimeSeconds: detail.Duration,
+				DistanceMeters:   detail.Distance,
+				Intensity:        "Active",
+				TriggerMethod:    "Manual",
+			},
+			Notes: detail.Activity.Name,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(database)
+}
@@ -0,0 +1,42 @@
+package main
+
+import "strings"
+
+const kilogramsPerPound = 0.45359237
+
+// Fitocracy reports weight units by abbreviation ("lb", "kg"), but be lenient
+// about the variations other apps and config files use
+func isKilograms(units string) bool {
+	switch strings.ToLower(strings.TrimSpace(units)) {
+	case "kg", "kgs", "kilogram", "kilograms":
+		return true
+	}
+	return false
+}
+
+func isPounds(units string) bool {
+	switch strings.ToLower(strings.TrimSpace(units)) {
+	case "lb", "lbs", "pound", "pounds":
+		return true
+	}
+	return false
+}
+
+// Whether a set's weight column holds a weight. Cardio synced before durations
+// were stored has its time or distance there instead, in units like "min" or
+// "mi", and bodyweight sets have units like "reps".
+func isWeightUnit(units string) bool {
+	return isPounds(units) || isKilograms(units)
+}
+
+// Convert a weight recorded in one unit into another. Anything that isn't a
+// recognizable weight unit (e.g. "reps" for bodyweight sets) is returned as-is.
+func ConvertWeight(weight float64, fromUnits string, toUnits string) float64 {
+	if isPounds(fromUnits) && isKilograms(toUnits) {
+		return weight * kilogramsPerPound
+	}
+	if isKilograms(fromUnits) && isPounds(toUnits) {
+		return weight / kilogramsPerPound
+	}
+	return weight
+}
@@ -3,28 +3,40 @@ package main
 import (
 	"encoding/csv"
 	"log"
-	"strconv"
+	"time"
 	"github.com/tlianza/fitocracypal/virtuagym"
 )
 
-type VirtuaGymCSVDumper struct{}
+// Dumps the sets that have a VirtuaGym mapping. Columns and Location are
+// optional; the default layout and UTC are used when they're not set.
+type VirtuaGymCSVDumper struct {
+	Columns  []string
+	Location *time.Location
+}
+
+func (c VirtuaGymCSVDumper) MappingTarget() string {
+	return "virtuagym"
+}
+
+func (c VirtuaGymCSVDumper) columns() []string {
+	if len(c.Columns) == 0 {
+		return defaultVirtuaGymColumns
+	}
+	return c.Columns
+}
+
+func (c VirtuaGymCSVDumper) Header() []string {
+	return csvHeader(c.columns())
+}
 
 func (c VirtuaGymCSVDumper) Dump(csvWriter *csv.Writer, userActivityDetail UserActivityDetail, exerciseMapper *ExerciseMapper) {
 	e := exerciseMapper.ByFitocracyId[userActivityDetail.Activity.Id]
 
 	if e.VirtuaGymId <= 0 {
-		//log.Printf("Unknown virtuagym exercise: %d, %s\n", userActivityDetail.Activity.Id, userActivityDetail.Name)
-		return //can't add to csv
+		return //can't add to csv, ExportFile reports these
 	}
 
-	if err := csvWriter.Write([]string{
-		userActivityDetail.PerformedAt.String(),
-		strconv.Itoa(e.VirtuaGymId),
-		userActivityDetail.Name,
-		strconv.FormatFloat(userActivityDetail.Reps, 'f', -1, 32),
-		strconv.FormatFloat(userActivityDetail.Weight, 'f', -1, 32),
-		userActivityDetail.Units,
-	}); err != nil {
+	if err := csvWriter.Write(csvRecord(c.columns(), userActivityDetail, e, c.Location)); err != nil {
 		log.Fatalln("error writing record to csv:", err)
 	}
 }
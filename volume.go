@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+var volumePeriods = []string{"week", "month"}
+
+// The start of the week (Monday) or month t falls in, in the location
+func periodStart(t time.Time, period string, location *time.Location) time.Time {
+	t = wallClock(t, location)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+	if period == "month" {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// How much of a set's tonnage counts towards its secondary muscles
+const secondaryMuscleShare = 0.5
+
+// The weight moved by each rep of a set, in units. Exercises flagged as
+// bodyweight in their metadata add the lifter's body weight to any weight
+// logged, when it's known.
+func setLoad(detail UserActivityDetail, exercise Exercise, units string, bodyWeight float64) float64 {
+	load := ConvertWeight(detail.Weight, detail.Units, units)
+	if !isWeightUnit(detail.Units) {
+		load = 0
+	}
+	if exercise.Bodyweight {
+		load += bodyWeight
+	}
+	return load
+}
+
+// Sets, reps and tonnage (reps x weight) for something in a period
+type VolumeRow struct {
+	Period  time.Time
+	Id      int
+	Name    string
+	Sets    float64
+	Reps    float64
+	Tonnage float64
+}
+
+// Volume options: how to group sets, and what to report weights in
+type VolumeOptions struct {
+	Period     string
+	By         string
+	Units      string
+	BodyWeight float64 //in units; 0 if unknown
+	Location   *time.Location
+}
+
+var volumeGroupings = []string{"exercise", "workout", "muscle", "total"}
+
+// Strength volume per period and exercise, workout name, muscle group or in
+// total, in period order and then by tonnage. Muscle groups come from the
+// exercise metadata; primary muscles get a set's whole tonnage and secondary
+// muscles secondaryMuscleShare of it.
+func Volume(details []UserActivityDetail, exerciseMapper *ExerciseMapper, options VolumeOptions) ([]VolumeRow, error) {
+	type key struct {
+		period time.Time
+		name   string
+	}
+	rows := map[key]*VolumeRow{}
+	add := func(period time.Time, id int, name string, share float64, reps float64, tonnage float64) {
+		k := key{period, name}
+		row, ok := rows[k]
+		if !ok {
+			row = &VolumeRow{Period: period, Id: id, Name: name}
+			rows[k] = row
+		}
+		row.Sets += share
+		row.Reps += share * reps
+		row.Tonnage += share * tonnage
+	}
+
+	for _, detail := range details {
+		if detail.IsCardio() {
+			continue
+		}
+		exercise := exerciseMapper.ByFitocracyId[detail.Activity.Id]
+		period := periodStart(detail.PerformedAt, options.Period, options.Location)
+		tonnage := detail.Reps * setLoad(detail, exercise, options.Units, options.BodyWeight)
+
+		switch options.By {
+		case "exercise":
+			add(period, detail.Activity.Id, detail.Activity.Name, 1, detail.Reps, tonnage)
+		case "workout":
+			name := detail.WorkoutName
+			if name == "" {
+				name = "(unnamed)"
+			}
+			add(period, 0, name, 1, detail.Reps, tonnage)
+		case "muscle":
+			for _, muscle := range exercise.PrimaryMuscles {
+				add(period, 0, muscle, 1, detail.Reps, tonnage)
+			}
+			for _, muscle := range exercise.SecondaryMuscles {
+				add(period, 0, muscle, secondaryMuscleShare, detail.Reps, tonnage)
+			}
+		case "total":
+			add(period, 0, "", 1, detail.Reps, tonnage)
+		default:
+			return nil, fmt.Errorf("unknown volume grouping %q", options.By)
+		}
+	}
+
+	volume := []VolumeRow{}
+	for _, row := range rows {
+		volume = append(volume, *row)
+	}
+	sort.Slice(volume, func(i, j int) bool {
+		if !volume[i].Period.Equal(volume[j].Period) {
+			return volume[i].Period.Before(volume[j].Period)
+		}
+		if volume[i].Tonnage != volume[j].Tonnage {
+			return volume[i].Tonnage > volume[j].Tonnage
+		}
+		return volume[i].Name < volume[j].Name
+	})
+	return volume, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodStart(t *testing.T) {
+	sunday := time.Date(2016, 1, 10, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2016, 1, 4, 0, 0, 0, 0, time.UTC), periodStart(sunday, "week", time.UTC))
+	assert.Equal(t, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), periodStart(sunday, "month", time.UTC))
+
+	//times are the wall clock they were logged at, so a set just after
+	//midnight stays in its month wherever the timezone is
+	newYork, _ := time.LoadLocation("America/New_York")
+	assert.Equal(t, time.Date(2016, 1, 1, 0, 0, 0, 0, newYork), periodStart(time.Date(2016, 1, 1, 3, 0, 0, 0, time.UTC), "month", newYork))
+}
+
+func TestVolumeByMuscle(t *testing.T) {
+	mapper := NewExerciseMapper([]Exercise{
+		{FitocracyId: 1, PrimaryMuscles: MuscleList{"chest"}, SecondaryMuscles: MuscleList{"triceps"}},
+		{FitocracyId: 2, PrimaryMuscles: MuscleList{"lats"}, Bodyweight: true},
+	})
+	details := []UserActivityDetail{
+		testSet(1, 4, 1, 5, 100, "kg"),
+		testSet(2, 4, 1, 5, 225, "lb"),
+		testSet(3, 5, 2, 10, 0, "reps"),
+	}
+	details[2].Activity = &Activity{Id: 2, Name: "Pull-Up"}
+
+	volume, err := Volume(details, mapper, VolumeOptions{Period: "week", By: "muscle", Units: "kg", BodyWeight: 80, Location: time.UTC})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chest", "lats", "triceps"}, []string{volume[0].Name, volume[1].Name, volume[2].Name})
+	assert.Equal(t, 2.0, volume[0].Sets)
+	assert.InDelta(t, 1010.3, volume[0].Tonnage, 0.05)
+	assert.Equal(t, 800.0, volume[1].Tonnage)
+	assert.Equal(t, 1.0, volume[2].Sets)
+}
@@ -0,0 +1,53 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", ColumnName(0))
+	assert.Equal(t, "Z", ColumnName(25))
+	assert.Equal(t, "AA", ColumnName(26))
+	assert.Equal(t, "AZ", ColumnName(51))
+	assert.Equal(t, "BA", ColumnName(52))
+}
+
+func TestDateSerial(t *testing.T) {
+	assert.Equal(t, float64(42488), DateSerial(time.Date(2016, 4, 28, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 42488.5, DateSerial(time.Date(2016, 4, 28, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestSheetNames(t *testing.T) {
+	w := NewWorkbook()
+	assert.Equal(t, "Dips - Triceps Version", w.AddSheet("Dips - Triceps Version").Name)
+	assert.Equal(t, "Running (treadmill)", w.AddSheet("Running [treadmill]").Name)
+	assert.Equal(t, "Standing One-Arm Dumbbell Trice", w.AddSheet("Standing One-Arm Dumbbell Triceps Extension").Name)
+	assert.Equal(t, "Standing One-Arm Dumbbell T (2)", w.AddSheet("Standing One-Arm Dumbbell Triceps Extension").Name)
+}
+
+func TestWrite(t *testing.T) {
+	w := NewWorkbook()
+	sheet := w.AddSheet("Barbell Squat")
+	sheet.AddRow(Header("Date"), Header("Weight"))
+	sheet.AddRow(Date(time.Date(2016, 4, 28, 0, 0, 0, 0, time.UTC)), Number(225))
+
+	var out bytes.Buffer
+	assert.Nil(t, w.Write(&out))
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.Nil(t, err)
+	contents := map[string]string{}
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		b, _ := ioutil.ReadAll(r)
+		contents[f.Name] = string(b)
+	}
+	assert.Contains(t, contents, "[Content_Types].xml")
+	assert.Contains(t, contents["xl/workbook.xml"], `<sheet name="Barbell Squat" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, contents["xl/worksheets/sheet1.xml"], `<c r="A2" s="1"><v>42488</v></c><c r="B2" s="0"><v>225</v></c>`)
+}